@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"retask/api/model"
+	"retask/internal/packing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CalculateBestPackagesBatch calculates many orders against the current pack sizes in a single request. Results are
+// returned in the order of the request, an order that can't be fulfilled carries its own error instead of failing the
+// whole batch.
+func (h *Handler) CalculateBestPackagesBatch(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	r := &model.CalculateBestPackagesBatchRequest{}
+	if err := parseRequest(req, r); err != nil {
+		logger.WithField("error", err).Error("failed to parse request")
+		writeResponse(rw, 400, nil, err, logger)
+		return
+	}
+
+	if len(r.Orders) == 0 {
+		writeResponse(rw, 400, nil, ErrNoOrders, logger)
+		return
+	}
+
+	if len(r.Orders) > h.conf.MaxBatchSize {
+		writeResponse(rw, 400, nil, ErrBatchTooLarge, logger)
+		return
+	}
+
+	packs := h.conf.GetPacks()
+	if err := validateStock(r.Stock, packs); err != nil {
+		writeResponse(rw, 400, nil, err, logger)
+		return
+	}
+
+	objective, err := packing.ParseObjective(r.Objective)
+	if err != nil {
+		writeResponse(rw, 400, nil, err, logger)
+		return
+	}
+
+	strategy, err := h.strategy(r.Strategy)
+	if err != nil {
+		writeResponse(rw, 400, nil, err, logger)
+		return
+	}
+
+	res := &model.CalculateBestPackagesBatchResponse{
+		Results: make([]model.BatchResult, len(r.Orders)),
+	}
+
+	// invalid orders fail on their own, only the valid ones are handed to the packaging repo.
+	var orders, indexes []int
+	for i, order := range r.Orders {
+		res.Results[i].Order = order
+		n, err := quantity(order)
+		if err != nil {
+			res.Results[i].Code, res.Results[i].Error = errorCode(err, 400), err.Error()
+			continue
+		}
+
+		orders = append(orders, n)
+		indexes = append(indexes, i)
+	}
+
+	results, err := h.packageRepo.SolveBatch(req.Context(), packs, orders, packing.Options{
+		Stock:         r.Stock,
+		Strategy:      strategy,
+		Objective:     objective,
+		Costs:         h.conf.PackCosts,
+		MaxTableBytes: h.conf.MaxTableSize,
+		OmitPackages:  true,
+	})
+	if err != nil {
+		writeCalculationError(rw, err, logger)
+		return
+	}
+
+	// the packs are only listed once the whole batch is known to hold at most maxPacks of them, an order that needs more
+	// on its own fails on its own.
+	total := 0
+	for _, result := range results {
+		if result.Err == nil {
+			if n := packCount(result.Result.Packs); h.conf.MaxPacks == 0 || n <= h.conf.MaxPacks {
+				total += n
+			}
+		}
+	}
+	if h.conf.MaxPacks > 0 && total > h.conf.MaxPacks {
+		err := fmt.Errorf("%w: %d packs in the batch, at most %d", packing.ErrTooManyPacks, total, h.conf.MaxPacks)
+		writeResponse(rw, 422, nil, err, logger)
+		return
+	}
+
+	for k, result := range results {
+		if result.Err == nil {
+			res.Results[indexes[k]].Packages, result.Err = packing.Ungroup(result.Result.Packs, h.conf.MaxPacks)
+		}
+
+		// a failed order is reported like a failed calculation would be, only without failing the batch.
+		if result.Err != nil {
+			status, err := calculationError(result.Err)
+			res.Results[indexes[k]].Code, res.Results[indexes[k]].Error = errorCode(err, status), err.Error()
+		}
+	}
+
+	writeResponse(rw, 200, res, nil, logger)
+}
+
+// packCount returns the number of packs in packs.
+func packCount(packs []packing.PackQuantity) int {
+	n := 0
+	for _, pack := range packs {
+		n += pack.Quantity
+	}
+
+	return n
+}
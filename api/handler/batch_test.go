@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"retask/api/model"
+	"retask/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateBestPackagesBatchCodes(t *testing.T) {
+	conf := &config.Config{}
+	conf.SetPacks([]int{250, 500, 1000, 2000, 5000}, "test")
+	h := newTestHandler(conf)
+
+	rw := serve(h.CalculateBestPackagesBatch, "POST", "/calculate-best-packages/batch", "/calculate-best-packages/batch",
+		`{"orders": [751, 0, 9223372036854775807, 12001], "stock": {"250": 0, "500": 0, "1000": 1, "2000": 0, "5000": 0}}`)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	out := &model.CalculateBestPackagesBatchResponse{}
+	assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+
+	codes := make([]string, 0, len(out.Results))
+	for _, result := range out.Results {
+		codes = append(codes, result.Code)
+		assert.Equal(t, result.Code == "", result.Error == "", "order %d", result.Order)
+	}
+
+	assert.Equal(t, []string{"", "order_invalid", "order_too_large", "insufficient_stock"}, codes)
+	assert.Equal(t, []int{1000}, out.Results[0].Packages)
+}
+
+func TestCalculateBestPackagesBatchMaxPacks(t *testing.T) {
+	tests := []struct {
+		body   string
+		status int
+		codes  []string
+	}{
+		// 50000 takes 10 packs, it fails on its own and doesn't count towards the batch.
+		{body: `{"orders": [12001, 50000]}`, status: http.StatusOK, codes: []string{"", "too_many_packs"}},
+		{body: `{"orders": [12001, 12001]}`, status: http.StatusUnprocessableEntity},
+	}
+
+	conf := &config.Config{MaxPacks: 4}
+	conf.SetPacks([]int{250, 500, 1000, 2000, 5000}, "test")
+	h := newTestHandler(conf)
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			rw := serve(h.CalculateBestPackagesBatch, "POST", "/calculate-best-packages/batch",
+				"/calculate-best-packages/batch", test.body)
+			assert.Equal(t, test.status, rw.Code)
+
+			if test.status != http.StatusOK {
+				out := &model.ErrorResponse{}
+				assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+				assert.Equal(t, "too_many_packs", out.Code)
+				return
+			}
+
+			out := &model.CalculateBestPackagesBatchResponse{}
+			assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+
+			codes := make([]string, 0, len(out.Results))
+			for _, result := range out.Results {
+				codes = append(codes, result.Code)
+			}
+			assert.Equal(t, test.codes, codes)
+			assert.Equal(t, []int{5000, 5000, 2000, 250}, out.Results[0].Packages)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"retask/api/model"
+	"retask/internal/packing"
+	"retask/internal/recommend"
+
+	"github.com/sirupsen/logrus"
+)
+
+// errorCodes are the codes of the error envelope. Clients switch on them rather than on messages, so once released a
+// code never changes. The first error the response error wraps decides, so wrapping errors go first.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrRequestMalformed, "request_malformed"},
+	{ErrNoPackages, "no_packages"},
+	{ErrPackagesHaveDuplicates, "packages_have_duplicates"},
+	{ErrPackageNotPositive, "package_not_positive"},
+	{ErrPackageTooSmall, "package_too_small"},
+	{ErrPackageTooLarge, "package_too_large"},
+	{ErrTooManyPackages, "too_many_packages"},
+	{ErrPackageSpecMissing, "package_spec_missing"},
+	{ErrPackageUnknown, "package_unknown"},
+	{ErrUnknownField, "unknown_field"},
+	{ErrPreconditionRequired, "precondition_required"},
+	{ErrVersionMismatch, "version_mismatch"},
+	{ErrVersionUnknown, "version_unknown"},
+	{ErrOrderInvalid, "order_invalid"},
+	{ErrOrderTooLarge, "order_too_large"},
+	{ErrStockInvalid, "stock_invalid"},
+	{ErrStockUnknownSize, "stock_unknown_size"},
+	{ErrExplainInvalid, "explain_invalid"},
+	{ErrMaxOverageInvalid, "max_overage_invalid"},
+	{ErrNoOrderLines, "no_order_lines"},
+	{ErrNoOrders, "no_orders"},
+	{ErrRangeInvalid, "range_invalid"},
+	{ErrRangeTooLarge, "range_too_large"},
+	{ErrMaxSizesInvalid, "max_sizes_invalid"},
+	{ErrCandidatesInvalid, "candidates_invalid"},
+	{ErrMaxSizesTooLarge, "max_sizes_too_large"},
+	{ErrCandidatesTooMany, "candidates_too_many"},
+	{ErrBatchTooLarge, "batch_too_large"},
+	{ErrUnknownSKU, "unknown_sku"},
+	{ErrInternalServerError, "internal_error"},
+	{ErrCalculationTimeout, "calculation_timeout"},
+	{ErrCalculationCanceled, "calculation_canceled"},
+	{ErrRouteNotFound, "route_not_found"},
+	{ErrMethodNotAllowed, "method_not_allowed"},
+	{packing.ErrStrategyUnknown, "strategy_unknown"},
+	{packing.ErrObjectiveInvalid, "objective_invalid"},
+	{packing.ErrNoSolution, "no_solution"},
+	{packing.ErrInsufficientStock, "insufficient_stock"},
+	{packing.ErrCostMissing, "cost_missing"},
+	{packing.ErrAnalysisTooLarge, "analysis_too_large"},
+	{packing.ErrPackTooLarge, "pack_too_large"},
+	{packing.ErrNoPackFits, "no_pack_fits"},
+	{packing.ErrSpecMissing, "spec_missing"},
+	{packing.ErrOrderTooLarge, "order_too_large"},
+	{packing.ErrTableTooLarge, "table_too_large"},
+	{packing.ErrTooManyPacks, "too_many_packs"},
+	{recommend.ErrHistogramEmpty, "histogram_empty"},
+	{recommend.ErrHistogramInvalid, "histogram_invalid"},
+	{recommend.ErrJobNotFound, "job_not_found"},
+	{recommend.ErrTooManyJobs, "too_many_jobs"},
+}
+
+// errorCode returns the code of err. An error without a code of its own falls back to one for its status.
+func errorCode(err error, statusCode int) string {
+	// an overage that can't be capped is a lack of solution the client asked for, so it tells them apart.
+	var noSolution *packing.NoSolutionError
+	if errors.As(err, &noSolution) {
+		return "overage_exceeded"
+	}
+
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+
+	switch statusCode {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusTooManyRequests:
+		return "too_many_requests"
+	case http.StatusUnprocessableEntity:
+		return "unprocessable"
+	}
+
+	return "internal_error"
+}
+
+// writeError writes err in the error envelope, details are left out if they are nil. Every violation of an invalid
+// request is detailed, unless details are given.
+func writeError(rw http.ResponseWriter, statusCode int, err error, details any, logger *logrus.Entry) {
+	var validation *validationError
+	if details == nil && errors.As(err, &validation) {
+		details = validation.violations()
+	}
+
+	// every handler's logger carries the request id, so support can find the logs of a failed request by it.
+	requestID, _ := logger.Data["request_id"].(string)
+
+	writeResponse(rw, statusCode, &model.ErrorResponse{
+		Code:      errorCode(err, statusCode),
+		Message:   err.Error(),
+		RequestID: requestID,
+		Details:   details,
+	}, nil, logger)
+}
+
+// NotFound answers requests to routes that don't exist in the error envelope, like any other error.
+func (h *Handler) NotFound(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	writeResponse(rw, 404, nil, fmt.Errorf("%w: %s %s", ErrRouteNotFound, req.Method, req.URL.Path), logger)
+}
+
+// MethodNotAllowed answers requests to routes that exist, but not for their method, in the error envelope.
+func (h *Handler) MethodNotAllowed(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	writeResponse(rw, 405, nil, fmt.Errorf("%w: %s %s", ErrMethodNotAllowed, req.Method, req.URL.Path), logger)
+}
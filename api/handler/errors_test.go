@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"retask/api/model"
+	"retask/config"
+	"retask/internal/packing"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{err: ErrNoPackages, status: 400, code: "no_packages"},
+		// a wrapping error decides over the error it wraps.
+		{err: fmt.Errorf("%w: %w", ErrRequestMalformed, ErrOrderInvalid), status: 400, code: "request_malformed"},
+		{err: fmt.Errorf("sku WIDGET: %w", packing.ErrTooManyPacks), status: 422, code: "too_many_packs"},
+		{err: &packing.NoSolutionError{}, status: 422, code: "overage_exceeded"},
+		{err: invalid([]fieldError{{field: "sizes[1]", err: ErrPackageTooLarge}}), status: 400, code: "package_too_large"},
+		// errors without a code of their own fall back to their status.
+		{err: fmt.Errorf("unexpected"), status: 400, code: "bad_request"},
+		{err: fmt.Errorf("unexpected"), status: 409, code: "conflict"},
+		{err: fmt.Errorf("unexpected"), status: 500, code: "internal_error"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			assert.Equal(t, test.code, errorCode(test.err, test.status))
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		err     error
+		status  int
+		given   any // details passed to writeError
+		details any // details in the response
+	}{
+		{err: ErrOrderInvalid, status: 400},
+		{err: invalid([]fieldError{{field: "sizes", err: ErrNoPackages}}), status: 400, details: []any{
+			map[string]any{"field": "sizes", "code": "no_packages", "message": ErrNoPackages.Error()},
+		}},
+		{err: &packing.NoSolutionError{Under: []int{250}}, status: 422, given: &model.Nearest{Under: []int{250}},
+			details: map[string]any{"under": []any{250.0}}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			logger := logrus.WithField("request_id", "abc")
+			writeError(rw, test.status, test.err, test.given, logger)
+
+			assert.Equal(t, test.status, rw.Code)
+			assert.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+
+			out := map[string]any{}
+			assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &out))
+			assert.Equal(t, errorCode(test.err, test.status), out["code"])
+			assert.Equal(t, test.err.Error(), out["message"])
+			assert.Equal(t, "abc", out["requestId"])
+			assert.Equal(t, test.details, out["details"])
+		})
+	}
+}
+
+func TestWriteCalculationError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{err: packing.ErrNoSolution, status: 422, code: "no_solution"},
+		{err: fmt.Errorf("%w: 4 packs", packing.ErrTooManyPacks), status: 422, code: "too_many_packs"},
+		{err: packing.ErrTableTooLarge, status: 422, code: "table_too_large"},
+		{err: context.DeadlineExceeded, status: 504, code: "calculation_timeout"},
+		{err: context.Canceled, status: 503, code: "calculation_canceled"},
+		{err: fmt.Errorf("unexpected"), status: 500, code: "internal_error"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			writeCalculationError(rw, test.err, logrus.WithField("request_id", "abc"))
+
+			assert.Equal(t, test.status, rw.Code)
+			out := &model.ErrorResponse{}
+			assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+			assert.Equal(t, test.code, out.Code)
+		})
+	}
+}
+
+func TestRouteErrors(t *testing.T) {
+	h := newTestHandler(&config.Config{})
+
+	tests := []struct {
+		fn     http.HandlerFunc
+		status int
+		code   string
+	}{
+		{fn: h.NotFound, status: 404, code: "route_not_found"},
+		{fn: h.MethodNotAllowed, status: 405, code: "method_not_allowed"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			test.fn(rw, httptest.NewRequest("GET", "/nope", nil))
+
+			assert.Equal(t, test.status, rw.Code)
+			out := &model.ErrorResponse{}
+			assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+			assert.Equal(t, test.code, out.Code)
+			assert.Equal(t, "failed_to_fetch", out.RequestID)
+		})
+	}
+}
@@ -1,36 +1,87 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"retask/api/model"
 	"retask/config"
+	"retask/internal/packing"
+	"retask/internal/recommend"
+	"strconv"
 
 	"github.com/sirupsen/logrus"
 )
 
 var (
+	ErrRequestMalformed       = fmt.Errorf("provided request body is not valid json")
 	ErrNoPackages             = fmt.Errorf("provided packages are empty")
 	ErrPackagesHaveDuplicates = fmt.Errorf("provided packages have duplicates")
+	ErrPackageNotPositive     = fmt.Errorf("provided package size is negative or zero")
+	ErrPackageTooSmall        = fmt.Errorf("provided package size is below the minimum size")
+	ErrPackageTooLarge        = fmt.Errorf("provided package size is above the maximum size")
+	ErrTooManyPackages        = fmt.Errorf("provided packages are more than allowed")
+	ErrPackageSpecMissing     = fmt.Errorf("provided package size has no weight and volume configured")
+	ErrPackageUnknown         = fmt.Errorf("provided package size is not a package size")
+	ErrPreconditionRequired   = fmt.Errorf("provided request has no If-Match header with the version it changes")
+	ErrVersionMismatch        = fmt.Errorf("provided version is not the current version")
+	ErrVersionUnknown         = fmt.Errorf("provided version is not a version of the package sizes")
+	ErrUnknownField           = fmt.Errorf("provided request has a field that is not known")
 	ErrOrderInvalid           = fmt.Errorf("provided order is negative or zero")
+	ErrOrderTooLarge          = fmt.Errorf("provided order is too large to count")
+	ErrStockInvalid           = fmt.Errorf("provided stock is negative")
+	ErrStockUnknownSize       = fmt.Errorf("provided stock contains a size that is not a package size")
+	ErrExplainInvalid         = fmt.Errorf("provided explain is not a boolean")
+	ErrMaxOverageInvalid      = fmt.Errorf("provided max overage is negative")
+	ErrNoOrderLines           = fmt.Errorf("provided order has no lines")
+	ErrNoOrders               = fmt.Errorf("provided batch has no orders")
+	ErrRangeInvalid           = fmt.Errorf("provided range is not a positive range of orders")
+	ErrRangeTooLarge          = fmt.Errorf("provided range has more orders than allowed")
+	ErrMaxSizesInvalid        = fmt.Errorf("provided max sizes is not a positive number")
+	ErrCandidatesInvalid      = fmt.Errorf("provided candidates are not a comma separated list of numbers")
+	ErrMaxSizesTooLarge       = fmt.Errorf("provided max sizes is more than allowed")
+	ErrCandidatesTooMany      = fmt.Errorf("provided candidates are more than allowed")
+	ErrBatchTooLarge          = fmt.Errorf("provided batch has more orders than allowed")
+	ErrUnknownSKU             = fmt.Errorf("provided order contains an unknown sku")
 	ErrInternalServerError    = fmt.Errorf("internal server error")
+	ErrCalculationTimeout     = fmt.Errorf("calculation did not finish within the request timeout")
+	ErrCalculationCanceled    = fmt.Errorf("calculation was canceled")
+	ErrRouteNotFound          = fmt.Errorf("route not found")
+	ErrMethodNotAllowed       = fmt.Errorf("method not allowed on route")
 )
 
 type PackagingRepo interface {
-	Calculate([]int, int) []int
+	Solve(context.Context, []int, int, packing.Options) (*packing.Result, error)
+	SolveBatch(context.Context, []int, []int, packing.Options) ([]packing.BatchResult, error)
+	Compare(context.Context, []int, []int, int, int, int, int) (*packing.Comparison, error)
+}
+
+type CacheRepo interface {
+	Stats() packing.CacheStats
+}
+
+type RecommendationRepo interface {
+	Start(recommend.Histogram, recommend.Options) (recommend.Job, error)
+	Get(string) (recommend.Job, error)
 }
 
 type Handler struct {
-	conf        *config.Config
-	packageRepo PackagingRepo
+	conf               *config.Config
+	packageRepo        PackagingRepo
+	cacheRepo          CacheRepo
+	recommendationRepo RecommendationRepo
 }
 
-func New(conf *config.Config, pr PackagingRepo) *Handler {
+func New(conf *config.Config, pr PackagingRepo, cr CacheRepo, rr RecommendationRepo) *Handler {
 	return &Handler{
-		conf:        conf,
-		packageRepo: pr,
+		conf:               conf,
+		packageRepo:        pr,
+		cacheRepo:          cr,
+		recommendationRepo: rr,
 	}
 }
 
@@ -42,23 +93,143 @@ func (h *Handler) CalculateBestPackages(rw http.ResponseWriter, req *http.Reques
 	logger := logrus.WithField("request_id", reqID)
 	logger.Debug("fetched request id")
 
+	c, ok := h.calculate(rw, req, logger, false)
+	if !ok {
+		return
+	}
+
+	res := &model.CalculateBestPackagesResponse{
+		Packages:           c.result.Packages,
+		CalculationDetails: c.details,
+	}
+
+	writeResponse(rw, 200, res, nil, logger)
+}
+
+// calculation is a solved calculate-best-packages request, everything but the distribution itself is already in the
+// shape of the response.
+type calculation struct {
+	request *model.CalculateBestPackagesRequest
+	result  *packing.Result
+	details model.CalculationDetails
+}
+
+// calculate parses, validates and solves a calculate-best-packages request, which every version of the endpoint
+// shares. If anything fails, it writes the error response itself and returns false.
+//
+// compact responses group the packs by size, so the packages, and the parcels and shipment that list them, are only
+// worked out if the request includes packages. Orders too large to list are answered then.
+func (h *Handler) calculate(
+	rw http.ResponseWriter, req *http.Request, logger *logrus.Entry, compact bool,
+) (*calculation, bool) {
 	r := &model.CalculateBestPackagesRequest{}
 	if err := parseRequest(req, r); err != nil {
 		logger.WithField("error", err).Error("failed to parse request")
+		writeResponse(rw, 400, nil, err, logger)
+		return nil, false
 	}
 
-	if r.Order <= 0 {
-		writeResponse(rw, 400, nil, ErrOrderInvalid, logger)
-		return
+	order, err := quantity(r.Order)
+	if err != nil {
+		writeResponse(rw, 400, nil, err, logger)
+		return nil, false
 	}
 
-	packs := h.packageRepo.Calculate(h.conf.GetPacks(), r.Order)
+	packs := h.conf.GetPacks()
+	if err := validateStock(r.Stock, packs); err != nil {
+		writeResponse(rw, 400, nil, err, logger)
+		return nil, false
+	}
 
-	res := &model.CalculateBestPackagesResponse{
-		Packages: packs,
+	objective, err := packing.ParseObjective(r.Objective)
+	if err != nil {
+		writeResponse(rw, 400, nil, err, logger)
+		return nil, false
 	}
 
-	writeResponse(rw, 200, res, nil, logger)
+	strategy, err := h.strategy(r.Strategy)
+	if err != nil {
+		writeResponse(rw, 400, nil, err, logger)
+		return nil, false
+	}
+
+	if (r.MaxOverage != nil && *r.MaxOverage < 0) || (r.MaxOveragePercent != nil && *r.MaxOveragePercent < 0) {
+		writeResponse(rw, 400, nil, ErrMaxOverageInvalid, logger)
+		return nil, false
+	}
+
+	opts := packing.Options{
+		Stock:             r.Stock,
+		Strategy:          strategy,
+		Objective:         objective,
+		Costs:             h.conf.PackCosts,
+		MaxOverage:        r.MaxOverage,
+		MaxOveragePercent: r.MaxOveragePercent,
+		ExactOnly:         r.ExactOnly,
+		MaxPacks:          h.conf.MaxPacks,
+		MaxTableBytes:     h.conf.MaxTableSize,
+		OmitPackages:      compact && !r.IncludePackages,
+	}
+	if r.IncludeAlternatives {
+		opts.Alternatives = h.conf.MaxAlternatives
+	}
+
+	if limits := h.conf.ParcelLimits; limits.Weight > 0 || limits.Volume > 0 {
+		opts.Parcel = &packing.ParcelLimits{Weight: limits.Weight, Volume: limits.Volume}
+		opts.Specs = h.packSpecs()
+	}
+
+	// explain=true is a support tool, it doesn't change the answer so it lives in the query rather than the body.
+	if explain := req.URL.Query().Get("explain"); explain != "" {
+		opts.Explain, err = strconv.ParseBool(explain)
+		if err != nil {
+			writeResponse(rw, 400, nil, ErrExplainInvalid, logger)
+			return nil, false
+		}
+	}
+
+	result, err := h.packageRepo.Solve(req.Context(), packs, order, opts)
+	if err != nil {
+		writeCalculationError(rw, err, logger)
+		return nil, false
+	}
+
+	details := model.CalculationDetails{
+		Alternatives: result.Alternatives,
+	}
+	if result.Explanation != nil {
+		details.Explanation = newExplanation(result.Explanation)
+	}
+
+	if s, ok := strategy.(packing.BelowStrategy); ok && s.Below() {
+		shipped := int64(0)
+		for _, pack := range result.Packs {
+			shipped += int64(pack.Size) * int64(pack.Quantity)
+		}
+		backordered := r.Order - shipped
+		details.Shipped, details.Backordered = &shipped, &backordered
+	}
+
+	for _, parcel := range result.Parcels {
+		details.Parcels = append(details.Parcels, model.Parcel{
+			Packages: parcel.Packages,
+			Weight:   parcel.Weight,
+			Volume:   parcel.Volume,
+		})
+	}
+
+	// the load plan only adds to the packing, a pack that doesn't fit the containers, e.g. a size added at runtime that is
+	// larger than a carton, leaves the plan out rather than failing a valid packing.
+	if len(h.conf.Containers) > 0 && !opts.OmitPackages {
+		shipment, err := packing.PlanLoad(result.Packages, h.containers())
+		if err != nil {
+			logger.WithField("error", err).Warn("failed to plan load, responding without shipment")
+		} else {
+			details.Shipment = newShipment(shipment)
+		}
+	}
+
+	return &calculation{request: r, result: result, details: details}, true
 }
 
 func (h *Handler) UpdatePackageSizes(rw http.ResponseWriter, req *http.Request) {
@@ -69,71 +240,247 @@ func (h *Handler) UpdatePackageSizes(rw http.ResponseWriter, req *http.Request)
 	logger := logrus.WithField("request_id", reqID)
 	logger.Debug("fetched request id")
 
+	check, err := ifMatch(req)
+	if err != nil {
+		writeResponse(rw, 428, nil, err, logger)
+		return
+	}
+
 	r := &model.UpdatePackageSizes{}
-	if err := parseRequest(req, r); err != nil {
+	unknown, err := parseStrictRequest(req, r)
+	if err != nil {
 		logger.WithField("error", err).Error("failed to parse request")
+		writeResponse(rw, 400, nil, err, logger)
+		return
 	}
 
-	if len(r.Sizes) == 0 {
-		writeResponse(rw, 400, nil, ErrNoPackages, logger)
+	if err := invalid(append(h.validatePackSet(r.Sizes), unknown...)); err != nil {
+		writeResponse(rw, 400, nil, err, logger)
 		return
 	}
 
-	checkDuplicates := make(map[int]int)
-	for _, item := range r.Sizes {
-		_, ok := checkDuplicates[item]
-		if ok {
-			writeResponse(rw, 400, nil, ErrPackagesHaveDuplicates, logger)
-			return
+	packs, version, err := h.conf.UpdatePacks(changedBy(req), func(_ []int, version int) ([]int, error) {
+		if err := check(version); err != nil {
+			return nil, err
 		}
 
-		checkDuplicates[item] += 1
+		return r.Sizes, nil
+	})
+	if err != nil {
+		writePacksError(rw, err, logger)
+		return
 	}
 
-	packs := h.conf.SetPacks(r.Sizes)
-
 	res := &model.UpdatePackageSizes{
 		Sizes: packs,
 	}
 
+	rw.Header().Set("ETag", etag(version))
 	writeResponse(rw, 200, res, nil, logger)
 }
 
+// CacheStats reports the hit and miss counts of the table cache.
+func (h *Handler) CacheStats(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	stats := h.cacheRepo.Stats()
+	res := &model.CacheStats{
+		Hits:    stats.Hits,
+		Misses:  stats.Misses,
+		Entries: stats.Entries,
+		Bytes:   stats.Bytes,
+	}
+
+	writeResponse(rw, 200, res, nil, logger)
+}
+
+// newExplanation converts the explanation of the packaging repo into its response model.
+func newExplanation(e *packing.Explanation) *model.Explanation {
+	out := &model.Explanation{
+		Objective: make([]string, 0, len(e.Objective)),
+		Items:     e.Items,
+		Overage:   e.Overage,
+		PackCount: e.PackCount,
+		Cost:      e.Cost,
+		Rejected:  make([]model.RejectedCandidate, 0, len(e.Rejected)),
+	}
+
+	for _, c := range e.Objective {
+		out.Objective = append(out.Objective, string(c))
+	}
+
+	for _, r := range e.Rejected {
+		out.Rejected = append(out.Rejected, model.RejectedCandidate{
+			Packages:  r.Packages,
+			Items:     r.Items,
+			Overage:   r.Overage,
+			PackCount: r.PackCount,
+			Cost:      r.Cost,
+			DecidedBy: string(r.DecidedBy),
+			Rule:      r.DecidedBy.Rule(),
+		})
+	}
+
+	return out
+}
+
+// newShipment converts a load plan of the packaging repo into its response model.
+func newShipment(loads []packing.Load) []model.ShipmentLoad {
+	if len(loads) == 0 {
+		return nil
+	}
+
+	out := make([]model.ShipmentLoad, 0, len(loads))
+	for _, l := range loads {
+		out = append(out, model.ShipmentLoad{
+			Container: l.Container,
+			Items:     l.Items,
+			Packages:  l.Packages,
+			Contents:  newShipment(l.Contents),
+		})
+	}
+
+	return out
+}
+
+// containers returns the configured levels of the load plan.
+func (h *Handler) containers() []packing.Container {
+	out := make([]packing.Container, 0, len(h.conf.Containers))
+	for _, c := range h.conf.Containers {
+		out = append(out, packing.Container{Name: c.Name, Capacity: c.Capacity})
+	}
+
+	return out
+}
+
+// packSpecs returns the configured weight and volume per pack size.
+func (h *Handler) packSpecs() map[int]packing.PackSpec {
+	out := make(map[int]packing.PackSpec, len(h.conf.PackSpecs))
+	for size, spec := range h.conf.PackSpecs {
+		out[size] = packing.PackSpec{Weight: spec.Weight, Volume: spec.Volume}
+	}
+
+	return out
+}
+
+// strategy resolves the strategy a request asked for. Product lines pick their own fulfilment policy, the config
+// decides for everyone else.
+func (h *Handler) strategy(name string) (packing.Strategy, error) {
+	if name == "" {
+		name = h.conf.Strategy
+	}
+
+	return packing.LookupStrategy(name)
+}
+
+// quantity converts an order quantity of a request to the int the packaging repo counts in.
+func quantity(n int64) (int, error) {
+	if n <= 0 {
+		return 0, ErrOrderInvalid
+	}
+
+	if n > math.MaxInt {
+		return 0, ErrOrderTooLarge
+	}
+
+	return int(n), nil
+}
+
+// validateStock makes sure stock only limits known package sizes and never goes below zero.
+func validateStock(stock map[int]int, packs []int) error {
+	known := make(map[int]bool, len(packs))
+	for _, pack := range packs {
+		known[pack] = true
+	}
+
+	for size, n := range stock {
+		if !known[size] {
+			return ErrStockUnknownSize
+		}
+
+		if n < 0 {
+			return ErrStockInvalid
+		}
+	}
+
+	return nil
+}
+
+// writeCalculationError maps an error returned by the packaging repo to a response. A strategy without a solution,
+// running out of stock, ranking by the cost of a size that has none configured, a pack set too large to analyse, a
+// pack too large for its container or parcel, limiting parcels with a size that has no weight and volume configured, or
+// an order too large to count or to pack within the resource limits is a valid request we can't fulfil (422), a
+// deadline means the request ran out of time (504), a cancellation means the client went away or the server is
+// shutting down (503). An overage that can't be capped as requested also tells the client what it could have instead.
+func writeCalculationError(rw http.ResponseWriter, err error, logger *logrus.Entry) {
+	logger.WithField("error", err).Error("failed to calculate best packages")
+
+	var noSolution *packing.NoSolutionError
+	if errors.As(err, &noSolution) {
+		writeError(rw, 422, err, &model.Nearest{Under: noSolution.Under, Over: noSolution.Over}, logger)
+		return
+	}
+
+	status, resErr := calculationError(err)
+	writeResponse(rw, status, nil, resErr, logger)
+}
+
+// calculationError returns the status and the error a failed calculation is answered with. Errors that aren't the
+// client's to fix are replaced, so they don't leak anything internal.
+func calculationError(err error) (int, error) {
+	switch {
+	case errors.Is(err, packing.ErrNoSolution), errors.Is(err, packing.ErrInsufficientStock),
+		errors.Is(err, packing.ErrCostMissing), errors.Is(err, packing.ErrAnalysisTooLarge),
+		errors.Is(err, packing.ErrPackTooLarge), errors.Is(err, packing.ErrNoPackFits),
+		errors.Is(err, packing.ErrSpecMissing), errors.Is(err, packing.ErrOrderTooLarge),
+		errors.Is(err, packing.ErrTableTooLarge), errors.Is(err, packing.ErrTooManyPacks):
+		return 422, err
+	case errors.Is(err, context.DeadlineExceeded):
+		return 504, ErrCalculationTimeout
+	case errors.Is(err, context.Canceled):
+		return 503, ErrCalculationCanceled
+	default:
+		return 500, ErrInternalServerError
+	}
+}
+
+// parseRequest decodes the json body of request into bodyStruct. Any error wraps ErrRequestMalformed.
 func parseRequest(request *http.Request, bodyStruct any) error {
 	b, err := io.ReadAll(request.Body)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", ErrRequestMalformed, err)
 	}
 	defer request.Body.Close()
 
 	if err := json.Unmarshal(b, bodyStruct); err != nil {
-		return err
+		return fmt.Errorf("%w: %w", ErrRequestMalformed, err)
 	}
 
 	return nil
 }
 
+// writeResponse writes body as json with the status code, or resErr in the error envelope if it is set.
 func writeResponse(rw http.ResponseWriter, statusCode int, body any, resErr error, logger *logrus.Entry) {
-	if statusCode != 200 {
-		rw.WriteHeader(statusCode)
-	}
-
 	if resErr != nil {
-		if _, err := rw.Write([]byte(resErr.Error())); err != nil {
-			logger.WithField("error", err).Error("failed to write response")
-		}
-
+		writeError(rw, statusCode, resErr, nil, logger)
 		return
 	}
 
 	b, err := json.MarshalIndent(body, "", "  ")
 	if err != nil {
 		logger.WithField("error", err).Error("failed to marshal json response")
-		if _, err := rw.Write([]byte(ErrInternalServerError.Error())); err != nil {
-			logger.WithField("error", err).Error("failed to write response")
-		}
+		writeError(rw, 500, ErrInternalServerError, nil, logger)
+		return
 	}
 
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(statusCode)
+
 	if _, err := rw.Write(b); err != nil {
 		logger.WithField("error", err).Error("failed to write response")
 	}
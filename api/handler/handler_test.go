@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"retask/api/model"
+	"retask/config"
+	"retask/internal/packing"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestHandler returns a handler over a real packaging repo. Settings conf leaves at zero are taken from config.yaml,
+// so tests only have to set what they are about.
+func newTestHandler(conf *config.Config) *Handler {
+	if conf.MaxPacks == 0 {
+		conf.MaxPacks = 1000000
+	}
+
+	if conf.MaxBatchSize == 0 {
+		conf.MaxBatchSize = 50000
+	}
+
+	if conf.Strategy == "" {
+		conf.Strategy = "min-items-then-min-packs"
+	}
+
+	cache := packing.NewCache(1 << 20)
+	return New(conf, packing.NewCached(cache), cache, nil)
+}
+
+// serve routes a request with body to fn, which is registered for pattern like server.New does, and records the
+// response. headers are pairs of header names and values.
+func serve(fn http.HandlerFunc, method, pattern, target, body string, headers ...string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.MethodFunc(method, pattern, fn)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rw := httptest.NewRecorder()
+	router.ServeHTTP(rw, req)
+
+	return rw
+}
+
+func TestCalculateBestPackagesShipment(t *testing.T) {
+	tests := []struct {
+		packs    []int
+		order    int
+		packages []int
+		shipment []model.ShipmentLoad
+	}{
+		{packs: []int{250, 500, 1000, 2000, 5000}, order: 12000, packages: []int{5000, 5000, 2000}, shipment: []model.ShipmentLoad{
+			{Container: "pallet", Items: 12000, Contents: []model.ShipmentLoad{
+				{Container: "carton", Items: 10000, Packages: []int{5000, 5000}},
+				{Container: "carton", Items: 2000, Packages: []int{2000}},
+			}},
+		}},
+		// a size larger than a carton can't be planned, which leaves the shipment out but still packs the order.
+		{packs: []int{250, 500, 20000}, order: 40000, packages: []int{20000, 20000}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			conf := &config.Config{Containers: []config.Container{{Name: "carton", Capacity: 10000}, {Name: "pallet", Capacity: 4}}}
+			conf.SetPacks(test.packs, "test")
+			h := newTestHandler(conf)
+
+			rw := serve(h.CalculateBestPackages, "POST", "/calculate-best-packages", "/calculate-best-packages",
+				fmt.Sprintf(`{"order": %d}`, test.order))
+
+			assert.Equal(t, http.StatusOK, rw.Code)
+			out := &model.CalculateBestPackagesResponse{}
+			assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+			assert.Equal(t, test.packages, out.Packages)
+			assert.Equal(t, test.shipment, out.Shipment)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"retask/api/model"
+	"retask/internal/packing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CalculateOrderPackages packs every line of a multi-SKU order with the pack sizes of its own SKU, and sums the lines
+// up into order level totals.
+func (h *Handler) CalculateOrderPackages(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	r := &model.CalculateOrderPackagesRequest{}
+	if err := parseRequest(req, r); err != nil {
+		logger.WithField("error", err).Error("failed to parse request")
+		writeResponse(rw, 400, nil, err, logger)
+		return
+	}
+
+	if len(r.Lines) == 0 {
+		writeResponse(rw, 400, nil, ErrNoOrderLines, logger)
+		return
+	}
+
+	// validate every line before solving any of them, so a bad line doesn't waste the work done for the others.
+	quantities := make([]int, len(r.Lines))
+	total := int64(0)
+	for i, line := range r.Lines {
+		if _, ok := h.conf.Catalogues[line.SKU]; !ok {
+			writeResponse(rw, 400, nil, fmt.Errorf("%w: %s", ErrUnknownSKU, line.SKU), logger)
+			return
+		}
+
+		n, err := quantity(line.Quantity)
+		if err != nil {
+			writeResponse(rw, 400, nil, err, logger)
+			return
+		}
+
+		// the totals have to be countable too.
+		if line.Quantity > math.MaxInt64-total {
+			writeResponse(rw, 400, nil, ErrOrderTooLarge, logger)
+			return
+		}
+
+		quantities[i] = n
+		total += line.Quantity
+	}
+
+	strategy, err := h.strategy(r.Strategy)
+	if err != nil {
+		writeResponse(rw, 400, nil, err, logger)
+		return
+	}
+
+	res := &model.CalculateOrderPackagesResponse{
+		Lines: make([]model.OrderLinePackages, 0, len(r.Lines)),
+	}
+	for i, line := range r.Lines {
+		result, err := h.packageRepo.Solve(req.Context(), h.conf.Catalogues[line.SKU], quantities[i], packing.Options{
+			Strategy:      strategy,
+			MaxPacks:      h.conf.MaxPacks,
+			MaxTableBytes: h.conf.MaxTableSize,
+		})
+		if err != nil {
+			writeCalculationError(rw, fmt.Errorf("sku %s: %w", line.SKU, err), logger)
+			return
+		}
+
+		// a line never sends more than a largest pack above its quantity, which the packaging repo keeps countable.
+		items := int64(0)
+		for _, pack := range result.Packages {
+			items += int64(pack)
+		}
+
+		// the quantities add up, but rounding every line up to its packs may still push the items past what we can
+		// count.
+		if items > math.MaxInt64-res.Totals.Items {
+			writeCalculationError(rw, fmt.Errorf("%w: the items of all lines", packing.ErrOrderTooLarge), logger)
+			return
+		}
+
+		res.Lines = append(res.Lines, model.OrderLinePackages{
+			SKU:       line.SKU,
+			Quantity:  line.Quantity,
+			Packages:  result.Packages,
+			Items:     items,
+			PackCount: len(result.Packages),
+		})
+
+		res.Totals.Quantity += line.Quantity
+		res.Totals.Items += items
+		res.Totals.PackCount += len(result.Packages)
+	}
+	res.Totals.Overage = res.Totals.Items - res.Totals.Quantity
+
+	writeResponse(rw, 200, res, nil, logger)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"retask/api/model"
+	"retask/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateOrderPackages(t *testing.T) {
+	tests := []struct {
+		body     string
+		status   int
+		expected *model.CalculateOrderPackagesResponse
+		code     string
+	}{
+		{
+			body:   `{"lines": [{"sku": "WIDGET", "quantity": 501}, {"sku": "BOLT", "quantity": 1000}]}`,
+			status: http.StatusOK,
+			expected: &model.CalculateOrderPackagesResponse{
+				Lines: []model.OrderLinePackages{
+					{SKU: "WIDGET", Quantity: 501, Packages: []int{500, 250}, Items: 750, PackCount: 2},
+					{
+						SKU:       "BOLT",
+						Quantity:  1000,
+						Packages:  []int{70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 34, 34, 11, 11},
+						Items:     1000,
+						PackCount: 17,
+					},
+				},
+				Totals: model.OrderTotals{Quantity: 1501, Items: 1750, Overage: 249, PackCount: 19},
+			},
+		},
+		{
+			// every line is packed with the sizes of its own sku, 7 can't be sent exactly with 3, 4 and 5 otherwise.
+			body:   `{"lines": [{"sku": "SCREW", "quantity": 7}, {"sku": "WIDGET", "quantity": 7}]}`,
+			status: http.StatusOK,
+			expected: &model.CalculateOrderPackagesResponse{
+				Lines: []model.OrderLinePackages{
+					{SKU: "SCREW", Quantity: 7, Packages: []int{4, 3}, Items: 7, PackCount: 2},
+					{SKU: "WIDGET", Quantity: 7, Packages: []int{250}, Items: 250, PackCount: 1},
+				},
+				Totals: model.OrderTotals{Quantity: 14, Items: 257, Overage: 243, PackCount: 3},
+			},
+		},
+		{body: `{"lines": [{"sku": "WIDGET", "quantity": 1}, {"sku": "NAIL", "quantity": 1}]}`, status: 400, code: "unknown_sku"},
+		{body: `{"lines": [{"sku": "WIDGET", "quantity": 0}]}`, status: 400, code: "order_invalid"},
+		{body: `{"lines": []}`, status: 400, code: "no_order_lines"},
+		{
+			body:   `{"lines": [{"sku": "WIDGET", "quantity": 9223372036854775807}, {"sku": "BOLT", "quantity": 1}]}`,
+			status: 400,
+			code:   "order_too_large",
+		},
+	}
+
+	h := newTestHandler(&config.Config{
+		Catalogues: map[string][]int{
+			"WIDGET": {250, 500, 1000, 2000, 5000},
+			"BOLT":   {11, 34, 59, 70},
+			"SCREW":  {3, 4, 5},
+		},
+	})
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			rw := serve(h.CalculateOrderPackages, "POST", "/calculate-order-packages", "/calculate-order-packages", test.body)
+
+			assert.Equal(t, test.status, rw.Code)
+			if test.expected != nil {
+				out := &model.CalculateOrderPackagesResponse{}
+				assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+				assert.Equal(t, test.expected, out)
+				return
+			}
+
+			out := &model.ErrorResponse{}
+			assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+			assert.Equal(t, test.code, out.Code)
+		})
+	}
+}
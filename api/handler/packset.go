@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"retask/api/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AnalyzePackSet reports what replacing the current package sizes with the proposed ones would do to waste, without
+// changing anything.
+func (h *Handler) AnalyzePackSet(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	r := &model.AnalyzePackSetRequest{}
+	if err := parseRequest(req, r); err != nil {
+		logger.WithField("error", err).Error("failed to parse request")
+		writeResponse(rw, 400, nil, err, logger)
+		return
+	}
+
+	if err := invalid(validateSizes(r.Sizes, h.conf.PackSizeLimits)); err != nil {
+		writeResponse(rw, 400, nil, err, logger)
+		return
+	}
+
+	if r.From <= 0 || r.To < r.From {
+		writeResponse(rw, 400, nil, ErrRangeInvalid, logger)
+		return
+	}
+
+	// every order in the range is solved twice, so it is capped like a batch.
+	if r.To-r.From >= int64(h.conf.MaxBatchSize) {
+		writeResponse(rw, 400, nil, ErrRangeTooLarge, logger)
+		return
+	}
+
+	to, err := quantity(r.To)
+	if err != nil {
+		writeResponse(rw, 400, nil, err, logger)
+		return
+	}
+
+	comparison, err := h.packageRepo.Compare(
+		req.Context(), r.Sizes, h.conf.GetPacks(), int(r.From), to, h.conf.MaxTableSize, h.conf.MaxPacks,
+	)
+	if err != nil {
+		writeCalculationError(rw, err, logger)
+		return
+	}
+
+	res := &model.AnalyzePackSetResponse{
+		GCD:             comparison.Analysis.Divisor,
+		Frobenius:       comparison.Analysis.Frobenius,
+		MaxOverage:      comparison.MaxOverage,
+		MaxOverageOrder: comparison.MaxOverageOrder,
+		AverageOverage:  comparison.AverageOverage,
+		Changed:         comparison.Changed,
+		Changes:         make([]model.PackSetChange, 0, len(comparison.Changes)),
+	}
+	for _, change := range comparison.Changes {
+		res.Changes = append(res.Changes, model.PackSetChange{
+			Order:    change.Order,
+			Current:  change.Current,
+			Proposed: change.Proposed,
+		})
+	}
+
+	writeResponse(rw, 200, res, nil, logger)
+}
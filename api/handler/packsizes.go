@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"retask/api/model"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// GetPackSizes returns the current package sizes.
+func (h *Handler) GetPackSizes(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	packs, version := h.conf.GetVersionedPacks()
+	res := &model.UpdatePackageSizes{
+		Sizes: packs,
+	}
+
+	rw.Header().Set("ETag", etag(version))
+	writeResponse(rw, 200, res, nil, logger)
+}
+
+// AddPackSize adds a single size to the current package sizes. A size that is already there, or one more size than the
+// limits allow, conflicts with the current sizes (409).
+func (h *Handler) AddPackSize(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	check, err := ifMatch(req)
+	if err != nil {
+		writeResponse(rw, 428, nil, err, logger)
+		return
+	}
+
+	r := &model.AddPackSize{}
+	errs, err := parseStrictRequest(req, r)
+	if err != nil {
+		logger.WithField("error", err).Error("failed to parse request")
+		writeResponse(rw, 400, nil, err, logger)
+		return
+	}
+
+	if err := validateSize(r.Size, h.conf.PackSizeLimits); err != nil {
+		errs = append([]fieldError{{field: "size", err: err}}, errs...)
+	} else if err := h.validateSpec(r.Size); err != nil {
+		errs = append([]fieldError{{field: "size", err: err}}, errs...)
+	}
+
+	if err := invalid(errs); err != nil {
+		writeResponse(rw, 400, nil, err, logger)
+		return
+	}
+
+	limit := h.conf.PackSizeLimits.MaxCount
+	packs, version, err := h.conf.UpdatePacks(changedBy(req), func(packs []int, version int) ([]int, error) {
+		if err := check(version); err != nil {
+			return nil, err
+		}
+
+		if slices.Contains(packs, r.Size) {
+			return nil, fmt.Errorf("%w: %d", ErrPackagesHaveDuplicates, r.Size)
+		}
+
+		if limit > 0 && len(packs) >= limit {
+			return nil, fmt.Errorf("%w: %d sizes, at most %d allowed", ErrTooManyPackages, len(packs)+1, limit)
+		}
+
+		return append(packs, r.Size), nil
+	})
+	if err != nil {
+		writePacksError(rw, err, logger)
+		return
+	}
+
+	res := &model.UpdatePackageSizes{
+		Sizes: packs,
+	}
+
+	rw.Header().Set("ETag", etag(version))
+	writeResponse(rw, 201, res, nil, logger)
+}
+
+// DeletePackSize removes a single size from the current package sizes. Unknown sizes are not found (404), and the last
+// size can't be removed, which would leave nothing to pack with (409).
+func (h *Handler) DeletePackSize(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	check, err := ifMatch(req)
+	if err != nil {
+		writeResponse(rw, 428, nil, err, logger)
+		return
+	}
+
+	// anything that isn't a number can't be a size either.
+	size, err := strconv.Atoi(chi.URLParam(req, "size"))
+	if err != nil {
+		writeResponse(rw, 404, nil, fmt.Errorf("%w: %s", ErrPackageUnknown, chi.URLParam(req, "size")), logger)
+		return
+	}
+
+	packs, version, err := h.conf.UpdatePacks(changedBy(req), func(packs []int, version int) ([]int, error) {
+		if err := check(version); err != nil {
+			return nil, err
+		}
+
+		i := slices.Index(packs, size)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %d", ErrPackageUnknown, size)
+		}
+
+		if len(packs) == 1 {
+			return nil, fmt.Errorf("%w: %d is the last size", ErrNoPackages, size)
+		}
+
+		return slices.Delete(packs, i, i+1), nil
+	})
+	if err != nil {
+		writePacksError(rw, err, logger)
+		return
+	}
+
+	res := &model.UpdatePackageSizes{
+		Sizes: packs,
+	}
+
+	rw.Header().Set("ETag", etag(version))
+	writeResponse(rw, 200, res, nil, logger)
+}
+
+// GetPackSizesHistory returns the versions of the package sizes kept in the history, oldest first.
+func (h *Handler) GetPackSizesHistory(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	history := h.conf.GetPacksHistory()
+	res := &model.PackSizesHistory{
+		Versions: make([]model.PackSizesVersion, 0, len(history)),
+	}
+	for _, v := range history {
+		res.Versions = append(res.Versions, model.PackSizesVersion{
+			Version:   v.Version,
+			From:      v.Previous,
+			To:        v.Packs,
+			ChangedBy: v.ChangedBy,
+			ChangedAt: v.ChangedAt,
+		})
+	}
+
+	rw.Header().Set("ETag", etag(history[len(history)-1].Version))
+	writeResponse(rw, 200, res, nil, logger)
+}
+
+// RollbackPackSizes sets the package sizes back to those of an earlier version. The rollback is recorded as a new
+// version like any other change, so it can be rolled back itself. The limits may have changed since the version was
+// recorded, so its sizes are validated like any other update, a version that isn't valid anymore conflicts (409).
+func (h *Handler) RollbackPackSizes(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	check, err := ifMatch(req)
+	if err != nil {
+		writeResponse(rw, 428, nil, err, logger)
+		return
+	}
+
+	// versions never change once recorded, so the one to roll back to can be looked up before locking for the change.
+	n, err := strconv.Atoi(chi.URLParam(req, "version"))
+	if err != nil {
+		writeResponse(rw, 404, nil, fmt.Errorf("%w: %s", ErrVersionUnknown, chi.URLParam(req, "version")), logger)
+		return
+	}
+
+	target, ok := h.conf.GetPacksVersion(n)
+	if !ok {
+		writeResponse(rw, 404, nil, fmt.Errorf("%w: %d", ErrVersionUnknown, n), logger)
+		return
+	}
+
+	if err := invalid(h.validatePackSet(target.Packs)); err != nil {
+		writeResponse(rw, 409, nil, err, logger)
+		return
+	}
+
+	packs, version, err := h.conf.UpdatePacks(changedBy(req), func(_ []int, version int) ([]int, error) {
+		if err := check(version); err != nil {
+			return nil, err
+		}
+
+		return slices.Clone(target.Packs), nil
+	})
+	if err != nil {
+		writePacksError(rw, err, logger)
+		return
+	}
+
+	res := &model.UpdatePackageSizes{
+		Sizes: packs,
+	}
+
+	rw.Header().Set("ETag", etag(version))
+	writeResponse(rw, 200, res, nil, logger)
+}
+
+// changedBy tells who changes the package sizes with req, the operator named by the X-Operator header or else the
+// address the request came from.
+func changedBy(req *http.Request) string {
+	if operator := req.Header.Get("X-Operator"); operator != "" {
+		return operator
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
+
+// ifMatch returns a check of the version of the pack set against the If-Match header of req, which every change of the
+// pack set requires. A change based on a version someone else has changed since would silently overwrite theirs.
+func ifMatch(req *http.Request) (func(version int) error, error) {
+	header := req.Header.Get("If-Match")
+	if header == "" {
+		return nil, ErrPreconditionRequired
+	}
+
+	return func(version int) error {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || tag == etag(version) {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("%w: the current version is %s", ErrVersionMismatch, etag(version))
+	}, nil
+}
+
+// etag is the ETag header of a version of the pack set.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// writePacksError maps an error of changing the pack set to a response. A version someone else has changed since fails
+// the precondition (412), an unknown size is not found (404), anything else conflicts with the current sizes (409).
+func writePacksError(rw http.ResponseWriter, err error, logger *logrus.Entry) {
+	switch {
+	case errors.Is(err, ErrVersionMismatch):
+		writeResponse(rw, 412, nil, err, logger)
+	case errors.Is(err, ErrPackageUnknown):
+		writeResponse(rw, 404, nil, err, logger)
+	default:
+		writeResponse(rw, 409, nil, err, logger)
+	}
+}
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"retask/api/model"
+	"retask/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// packSizesRoute is a route of the pack-sizes endpoints, registered like server.New does.
+type packSizesRoute struct {
+	method, pattern string
+	fn              func(h *Handler, rw http.ResponseWriter, req *http.Request)
+}
+
+var (
+	getPackSizes   = packSizesRoute{"GET", "/pack-sizes", (*Handler).GetPackSizes}
+	putPackSizes   = packSizesRoute{"PUT", "/pack-sizes", (*Handler).UpdatePackageSizes}
+	postPackSize   = packSizesRoute{"POST", "/pack-sizes", (*Handler).AddPackSize}
+	deletePackSize = packSizesRoute{"DELETE", "/pack-sizes/{size}", (*Handler).DeletePackSize}
+	getHistory     = packSizesRoute{"GET", "/pack-sizes/history", (*Handler).GetPackSizesHistory}
+	rollback       = packSizesRoute{"POST", "/pack-sizes/rollback/{version}", (*Handler).RollbackPackSizes}
+)
+
+// serve serves a request to target with h, see serve.
+func (r packSizesRoute) serve(h *Handler, target, body string, headers ...string) *httptest.ResponseRecorder {
+	fn := func(rw http.ResponseWriter, req *http.Request) { r.fn(h, rw, req) }
+
+	return serve(fn, r.method, r.pattern, target, body, headers...)
+}
+
+// newPackSizesHandler returns a handler whose pack sizes are at version 1 with packs.
+func newPackSizesHandler(packs []int, limits config.PackSizeLimits) *Handler {
+	conf := &config.Config{PackSizeLimits: limits}
+	conf.SetPacks(packs, "config")
+
+	return newTestHandler(conf)
+}
+
+func TestPackSizes(t *testing.T) {
+	tests := []struct {
+		route  packSizesRoute
+		target string
+		body   string
+		status int
+		code   string
+		sizes  []int
+		etag   string
+	}{
+		{route: getPackSizes, target: "/pack-sizes", status: 200, sizes: []int{250, 500, 1000}, etag: `"1"`},
+		// replacing sorts the sizes.
+		{route: putPackSizes, target: "/pack-sizes", body: `{"sizes": [750, 23]}`, status: 200, sizes: []int{23, 750},
+			etag: `"2"`},
+		// the same sizes in another order are no change, which keeps the version.
+		{route: putPackSizes, target: "/pack-sizes", body: `{"sizes": [1000, 500, 250]}`, status: 200,
+			sizes: []int{250, 500, 1000}, etag: `"1"`},
+		{route: postPackSize, target: "/pack-sizes", body: `{"size": 750}`, status: 201, sizes: []int{250, 500, 750, 1000},
+			etag: `"2"`},
+		{route: postPackSize, target: "/pack-sizes", body: `{"size": 500}`, status: 409, code: "packages_have_duplicates"},
+		{route: postPackSize, target: "/pack-sizes", body: `{"size": 0}`, status: 400, code: "package_not_positive"},
+		{route: postPackSize, target: "/pack-sizes", body: `{"sizes": [750]}`, status: 400, code: "package_not_positive"},
+		{route: deletePackSize, target: "/pack-sizes/500", status: 200, sizes: []int{250, 1000}, etag: `"2"`},
+		{route: deletePackSize, target: "/pack-sizes/750", status: 404, code: "package_unknown"},
+		{route: deletePackSize, target: "/pack-sizes/abc", status: 404, code: "package_unknown"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			h := newPackSizesHandler([]int{250, 500, 1000}, config.PackSizeLimits{})
+
+			rw := test.route.serve(h, test.target, test.body, "If-Match", "*")
+
+			assert.Equal(t, test.status, rw.Code)
+			if test.code != "" {
+				out := &model.ErrorResponse{}
+				assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+				assert.Equal(t, test.code, out.Code)
+				assert.Equal(t, []int{250, 500, 1000}, h.conf.GetPacks())
+				return
+			}
+
+			out := &model.UpdatePackageSizes{}
+			assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+			assert.Equal(t, test.sizes, out.Sizes)
+			assert.Equal(t, test.sizes, h.conf.GetPacks())
+			assert.Equal(t, test.etag, rw.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestPackSizesLimits(t *testing.T) {
+	// adding a size is checked against the limits like replacing them all.
+	h := newPackSizesHandler([]int{250, 500}, config.PackSizeLimits{MinSize: 100, MaxCount: 2})
+
+	for _, test := range []struct {
+		body string
+		code string
+	}{
+		{body: `{"size": 50}`, code: "package_too_small"},
+		{body: `{"size": 750}`, code: "too_many_packages"},
+	} {
+		rw := postPackSize.serve(h, "/pack-sizes", test.body, "If-Match", "*")
+
+		out := &model.ErrorResponse{}
+		assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+		assert.Equal(t, test.code, out.Code, test.body)
+	}
+
+	// the last size can't be removed, that would leave nothing to pack with.
+	h = newPackSizesHandler([]int{250}, config.PackSizeLimits{})
+	rw := deletePackSize.serve(h, "/pack-sizes/250", "", "If-Match", "*")
+	assert.Equal(t, http.StatusConflict, rw.Code)
+	assert.Equal(t, []int{250}, h.conf.GetPacks())
+}
+
+func TestPackSizesIfMatch(t *testing.T) {
+	tests := []struct {
+		headers []string
+		status  int
+		code    string
+	}{
+		{status: 428, code: "precondition_required"},
+		{headers: []string{"If-Match", `"1"`}, status: 412, code: "version_mismatch"},
+		{headers: []string{"If-Match", `"3"`}, status: 412, code: "version_mismatch"},
+		{headers: []string{"If-Match", `"2"`}},
+		{headers: []string{"If-Match", `"1", "2"`}},
+		{headers: []string{"If-Match", "*"}},
+	}
+
+	routes := []struct {
+		route  packSizesRoute
+		target string
+		body   string
+		status int
+	}{
+		{route: putPackSizes, target: "/pack-sizes", body: `{"sizes": [23, 31]}`, status: 200},
+		{route: postPackSize, target: "/pack-sizes", body: `{"size": 750}`, status: 201},
+		{route: deletePackSize, target: "/pack-sizes/500", status: 200},
+	}
+
+	for i, route := range routes {
+		for k, test := range tests {
+			t.Run(fmt.Sprintf("%d/%d", i, k), func(t *testing.T) {
+				// version 2 is the current one, a client that read version 1 is behind.
+				h := newPackSizesHandler([]int{250}, config.PackSizeLimits{})
+				h.conf.SetPacks([]int{250, 500}, "test")
+
+				rw := route.route.serve(h, route.target, route.body, test.headers...)
+
+				if test.code == "" {
+					assert.Equal(t, route.status, rw.Code)
+					assert.Equal(t, `"3"`, rw.Header().Get("ETag"))
+					return
+				}
+
+				assert.Equal(t, test.status, rw.Code)
+				out := &model.ErrorResponse{}
+				assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+				assert.Equal(t, test.code, out.Code)
+				assert.Equal(t, []int{250, 500}, h.conf.GetPacks())
+			})
+		}
+	}
+}
+
+func TestPackSizesHistory(t *testing.T) {
+	h := newPackSizesHandler([]int{250, 500}, config.PackSizeLimits{})
+
+	rw := putPackSizes.serve(h, "/pack-sizes", `{"sizes": [23, 31]}`, "If-Match", `"1"`, "X-Operator", "alice")
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	// without an operator, the change is put down to the address it came from.
+	rw = rollback.serve(h, "/pack-sizes/rollback/1", "", "If-Match", `"2"`)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, `"3"`, rw.Header().Get("ETag"))
+
+	rw = getHistory.serve(h, "/pack-sizes/history", "")
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, `"3"`, rw.Header().Get("ETag"))
+
+	out := &model.PackSizesHistory{}
+	assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+	for i := range out.Versions {
+		assert.False(t, out.Versions[i].ChangedAt.IsZero())
+		out.Versions[i].ChangedAt = time.Time{}
+	}
+
+	assert.Equal(t, []model.PackSizesVersion{
+		{Version: 1, To: []int{250, 500}, ChangedBy: "config"},
+		{Version: 2, From: []int{250, 500}, To: []int{23, 31}, ChangedBy: "alice"},
+		{Version: 3, From: []int{23, 31}, To: []int{250, 500}, ChangedBy: "192.0.2.1"},
+	}, out.Versions)
+}
+
+func TestRollbackPackSizes(t *testing.T) {
+	tests := []struct {
+		limits  config.PackSizeLimits
+		parcels config.ParcelLimits
+		first   []int // sizes of version 1, version 2 is 250 and 500
+		target  string
+		ifMatch string
+		status  int
+		code    string
+		sizes   []int
+		version int
+	}{
+		{first: []int{250, 750}, target: "/pack-sizes/rollback/1", ifMatch: `"2"`, status: 200, sizes: []int{250, 750},
+			version: 3},
+		// rolling back to the current version is no change.
+		{first: []int{250, 750}, target: "/pack-sizes/rollback/2", ifMatch: `"2"`, status: 200, sizes: []int{250, 500},
+			version: 2},
+		{first: []int{250, 750}, target: "/pack-sizes/rollback/1", status: 428, code: "precondition_required"},
+		{first: []int{250, 750}, target: "/pack-sizes/rollback/1", ifMatch: `"1"`, status: 412, code: "version_mismatch"},
+		{first: []int{250, 750}, target: "/pack-sizes/rollback/3", ifMatch: "*", status: 404, code: "version_unknown"},
+		{first: []int{250, 750}, target: "/pack-sizes/rollback/abc", ifMatch: "*", status: 404, code: "version_unknown"},
+		// versions are checked against the limits of today, not those they were recorded under.
+		{limits: config.PackSizeLimits{MaxSize: 1000}, first: []int{250, 5000}, target: "/pack-sizes/rollback/1",
+			ifMatch: "*", status: 409, code: "package_too_large"},
+		{parcels: config.ParcelLimits{Weight: 31500}, first: []int{250, 750}, target: "/pack-sizes/rollback/1",
+			ifMatch: "*", status: 409, code: "package_spec_missing"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			conf := &config.Config{
+				PackSizeLimits: test.limits,
+				ParcelLimits:   test.parcels,
+				PackSpecs:      map[int]config.PackSpec{250: {Weight: 270, Volume: 1000}, 500: {Weight: 520, Volume: 2000}},
+			}
+			conf.SetPacks(test.first, "config")
+			conf.SetPacks([]int{250, 500}, "alice")
+			h := newTestHandler(conf)
+
+			var headers []string
+			if test.ifMatch != "" {
+				headers = []string{"If-Match", test.ifMatch}
+			}
+			rw := rollback.serve(h, test.target, "", headers...)
+
+			assert.Equal(t, test.status, rw.Code)
+			if test.code != "" {
+				out := &model.ErrorResponse{}
+				assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+				assert.Equal(t, test.code, out.Code)
+				assert.Equal(t, []int{250, 500}, h.conf.GetPacks())
+				return
+			}
+
+			out := &model.UpdatePackageSizes{}
+			assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+			assert.Equal(t, test.sizes, out.Sizes)
+			assert.Equal(t, etag(test.version), rw.Header().Get("ETag"))
+
+			packs, version := h.conf.GetVersionedPacks()
+			assert.Equal(t, test.sizes, packs)
+			assert.Equal(t, test.version, version)
+		})
+	}
+}
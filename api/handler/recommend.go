@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"retask/api/model"
+	"retask/internal/recommend"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// StartRecommendation starts searching for the pack sizes that would have served a history of orders best. The search
+// runs in the background, the response carries the id of the job to poll with GetRecommendation.
+//
+// The history is either the json body, or a csv of quantity,count lines sent as text/csv, in which case maxSizes and
+// candidates are read from the query.
+func (h *Handler) StartRecommendation(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	var histogram recommend.Histogram
+	var opts recommend.Options
+	var err error
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "text/csv") {
+		histogram, opts, err = parseRecommendationCSV(req)
+	} else {
+		histogram, opts, err = parseRecommendationJSON(req, logger)
+	}
+	if err != nil {
+		writeResponse(rw, 400, nil, err, logger)
+		return
+	}
+
+	if opts.MaxSizes <= 0 {
+		writeResponse(rw, 400, nil, ErrMaxSizesInvalid, logger)
+		return
+	}
+
+	// the search grows with both the sizes and the candidates, so both are bounded.
+	if opts.MaxSizes > h.conf.RecommendMaxSizes {
+		writeResponse(rw, 400, nil, ErrMaxSizesTooLarge, logger)
+		return
+	}
+
+	if len(opts.Candidates) > h.conf.RecommendMaxCandidates {
+		writeResponse(rw, 400, nil, ErrCandidatesTooMany, logger)
+		return
+	}
+
+	for _, c := range opts.Candidates {
+		if c <= 0 {
+			writeResponse(rw, 400, nil, ErrCandidatesInvalid, logger)
+			return
+		}
+	}
+
+	job, err := h.recommendationRepo.Start(histogram, opts)
+	if errors.Is(err, recommend.ErrTooManyJobs) {
+		writeResponse(rw, 429, nil, err, logger)
+		return
+	}
+	if err != nil {
+		logger.WithField("error", err).Error("failed to start recommendation")
+		writeResponse(rw, 500, nil, ErrInternalServerError, logger)
+		return
+	}
+
+	writeResponse(rw, 202, newRecommendationJob(job), nil, logger)
+}
+
+// GetRecommendation returns the state of a recommendation job, and its result once it is done.
+func (h *Handler) GetRecommendation(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	job, err := h.recommendationRepo.Get(chi.URLParam(req, "id"))
+	if errors.Is(err, recommend.ErrJobNotFound) {
+		writeResponse(rw, 404, nil, err, logger)
+		return
+	}
+	if err != nil {
+		logger.WithField("error", err).Error("failed to get recommendation")
+		writeResponse(rw, 500, nil, ErrInternalServerError, logger)
+		return
+	}
+
+	writeResponse(rw, 200, newRecommendationJob(job), nil, logger)
+}
+
+func parseRecommendationJSON(req *http.Request, logger *logrus.Entry) (recommend.Histogram, recommend.Options, error) {
+	r := &model.StartRecommendationRequest{}
+	if err := parseRequest(req, r); err != nil {
+		logger.WithField("error", err).Error("failed to parse request")
+		return nil, recommend.Options{}, err
+	}
+
+	entries := make([]recommend.Entry, 0, len(r.Histogram))
+	for _, e := range r.Histogram {
+		entries = append(entries, recommend.Entry{Quantity: e.Quantity, Count: e.Count})
+	}
+
+	histogram, err := recommend.NewHistogram(entries)
+	if err != nil {
+		return nil, recommend.Options{}, err
+	}
+
+	return histogram, recommend.Options{MaxSizes: r.MaxSizes, Candidates: r.Candidates}, nil
+}
+
+func parseRecommendationCSV(req *http.Request) (recommend.Histogram, recommend.Options, error) {
+	defer req.Body.Close()
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, recommend.Options{}, fmt.Errorf("%w: %w", ErrRequestMalformed, err)
+	}
+
+	histogram, err := recommend.ParseCSV(bytes.NewReader(b))
+	if err != nil {
+		return nil, recommend.Options{}, err
+	}
+
+	query := req.URL.Query()
+	maxSizes, err := strconv.Atoi(query.Get("maxSizes"))
+	if err != nil {
+		return nil, recommend.Options{}, ErrMaxSizesInvalid
+	}
+
+	candidates, err := parseSizes(query.Get("candidates"))
+	if err != nil {
+		return nil, recommend.Options{}, ErrCandidatesInvalid
+	}
+
+	return histogram, recommend.Options{MaxSizes: maxSizes, Candidates: candidates}, nil
+}
+
+// parseSizes parses a comma separated list of sizes, an empty string is an empty list.
+func parseSizes(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var out []int
+	for _, field := range strings.Split(s, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, size)
+	}
+
+	return out, nil
+}
+
+// newRecommendationJob converts a job of the recommendation repo into its response model.
+func newRecommendationJob(job recommend.Job) *model.RecommendationJob {
+	out := &model.RecommendationJob{
+		ID:      job.ID,
+		Status:  string(job.Status),
+		Created: job.Created,
+	}
+
+	if !job.Finished.IsZero() {
+		out.Finished = &job.Finished
+	}
+
+	if job.Err != nil {
+		out.Error = job.Err.Error()
+	}
+
+	if job.Result != nil {
+		out.Result = &model.Recommendation{
+			Sizes:     job.Result.Sizes,
+			Orders:    job.Result.Orders,
+			Overage:   job.Result.Overage,
+			PackCount: job.Result.PackCount,
+			Evaluated: job.Result.Evaluated,
+		}
+	}
+
+	return out
+}
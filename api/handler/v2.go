@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"retask/api/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CalculateBestPackagesV2 calculates the same distribution as CalculateBestPackages, but groups it by size and adds
+// up its totals instead of listing every single pack, which gets enormous for large orders. Nothing that lists every
+// pack is worked out unless the request includes packages, so maxPacks doesn't apply to it either.
+func (h *Handler) CalculateBestPackagesV2(rw http.ResponseWriter, req *http.Request) {
+	reqID, ok := req.Context().Value("request_id").(string)
+	if !ok {
+		reqID = "failed_to_fetch"
+	}
+	logger := logrus.WithField("request_id", reqID)
+	logger.Debug("fetched request id")
+
+	c, ok := h.calculate(rw, req, logger, true)
+	if !ok {
+		return
+	}
+
+	res := &model.CalculateBestPackagesV2Response{
+		Packs:              make([]model.PackQuantity, 0, len(c.result.Packs)),
+		CalculationDetails: c.details,
+	}
+	for _, pack := range c.result.Packs {
+		res.Packs = append(res.Packs, model.PackQuantity{Size: pack.Size, Quantity: pack.Quantity})
+		// the packaging repo never sends more than a largest pack above the order, so this can't overflow.
+		res.TotalItems += int64(pack.Size) * int64(pack.Quantity)
+		res.PackCount += pack.Quantity
+	}
+	res.Overage = res.TotalItems - c.request.Order
+
+	if c.request.IncludePackages {
+		res.Packages = c.result.Packages
+	}
+
+	writeResponse(rw, 200, res, nil, logger)
+}
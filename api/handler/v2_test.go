@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"retask/api/model"
+	"retask/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateBestPackagesV2(t *testing.T) {
+	tests := []struct {
+		body     string
+		status   int
+		code     string
+		packs    []model.PackQuantity
+		packages []int
+		shipment bool
+	}{
+		{body: `{"order": 12000}`, status: 200, packs: []model.PackQuantity{{Size: 5000, Quantity: 2}, {Size: 2000, Quantity: 1}}},
+		{body: `{"order": 12000, "includePackages": true}`, status: 200,
+			packs:    []model.PackQuantity{{Size: 5000, Quantity: 2}, {Size: 2000, Quantity: 1}},
+			packages: []int{5000, 5000, 2000}, shipment: true},
+		// far more packs than maxPacks, which only bounds the packages.
+		{body: `{"order": 10000000001}`, status: 200,
+			packs: []model.PackQuantity{{Size: 5000, Quantity: 2000000}, {Size: 250, Quantity: 1}}},
+		{body: `{"order": 10000000001, "includePackages": true}`, status: 422, code: "too_many_packs"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			conf := &config.Config{Containers: []config.Container{{Name: "carton", Capacity: 10000}}}
+			conf.SetPacks([]int{250, 500, 1000, 2000, 5000}, "test")
+			h := newTestHandler(conf)
+
+			rw := serve(h.CalculateBestPackagesV2, "POST", "/v2/calculate-best-packages", "/v2/calculate-best-packages",
+				test.body)
+
+			assert.Equal(t, test.status, rw.Code)
+			if test.code != "" {
+				out := &model.ErrorResponse{}
+				assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+				assert.Equal(t, test.code, out.Code)
+				return
+			}
+
+			out := &model.CalculateBestPackagesV2Response{}
+			assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+			assert.Equal(t, test.packs, out.Packs)
+			assert.Equal(t, test.packages, out.Packages)
+			assert.Equal(t, test.shipment, out.Shipment != nil)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"retask/api/model"
+	"retask/config"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// fieldError is a single violation of a request, field is the json path of the offending value.
+type fieldError struct {
+	field string
+	err   error
+}
+
+// validationError holds every violation of a request at once, so a client can fix them all in one go. It wraps the
+// error of the first violation, which keeps the code of requests with a single violation what it has always been.
+type validationError struct {
+	errs []fieldError
+}
+
+func (e *validationError) Error() string {
+	if len(e.errs) == 1 {
+		return e.errs[0].err.Error()
+	}
+
+	return fmt.Sprintf("%s (and %d more violations)", e.errs[0].err, len(e.errs)-1)
+}
+
+func (e *validationError) Unwrap() error {
+	return e.errs[0].err
+}
+
+// violations converts the violations into the details of the error response.
+func (e *validationError) violations() []model.Violation {
+	out := make([]model.Violation, 0, len(e.errs))
+	for _, fe := range e.errs {
+		out = append(out, model.Violation{
+			Field:   fe.field,
+			Code:    errorCode(fe.err, http.StatusBadRequest),
+			Message: fe.err.Error(),
+		})
+	}
+
+	return out
+}
+
+// invalid returns the violations as an error, nil if there are none.
+func invalid(errs []fieldError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &validationError{errs: errs}
+}
+
+// parseStrictRequest is parseRequest for requests that change the service. It also returns a violation for every field
+// of the body that bodyStruct doesn't have, so a typo fails the request rather than being silently ignored.
+func parseStrictRequest(request *http.Request, bodyStruct any) ([]fieldError, error) {
+	b, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRequestMalformed, err)
+	}
+	defer request.Body.Close()
+
+	if err := json.Unmarshal(b, bodyStruct); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRequestMalformed, err)
+	}
+
+	// anything that decodes into a struct but not into an object is null, which has no fields at all.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRequestMalformed, err)
+	}
+
+	known := jsonFields(bodyStruct)
+
+	var errs []fieldError
+	for name := range fields {
+		// encoding/json matches field names case insensitively, so a field it decoded is never reported.
+		if !slices.ContainsFunc(known, func(n string) bool { return strings.EqualFold(n, name) }) {
+			errs = append(errs, fieldError{field: name, err: ErrUnknownField})
+		}
+	}
+
+	// maps have no order, but the violations should be the same every time.
+	sort.Slice(errs, func(i, j int) bool { return errs[i].field < errs[j].field })
+
+	return errs, nil
+}
+
+// jsonFields returns the json names of the fields of the struct bodyStruct points to.
+func jsonFields(bodyStruct any) []string {
+	t := reflect.TypeOf(bodyStruct).Elem()
+
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = t.Field(i).Name
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// validateSizes makes sure a set of package sizes is not empty, has no duplicates, and that both its sizes and its
+// number of sizes are within the limits. Sizes have to be positive no matter the limits, the packaging repo can't
+// pack anything with the others.
+func validateSizes(sizes []int, limits config.PackSizeLimits) []fieldError {
+	if len(sizes) == 0 {
+		return []fieldError{{field: "sizes", err: ErrNoPackages}}
+	}
+
+	var errs []fieldError
+	if limits.MaxCount > 0 && len(sizes) > limits.MaxCount {
+		errs = append(errs, fieldError{
+			field: "sizes",
+			err:   fmt.Errorf("%w: %d sizes, at most %d allowed", ErrTooManyPackages, len(sizes), limits.MaxCount),
+		})
+	}
+
+	seen := make(map[int]bool, len(sizes))
+	for i, size := range sizes {
+		field := fmt.Sprintf("sizes[%d]", i)
+
+		if seen[size] {
+			errs = append(errs, fieldError{field: field, err: fmt.Errorf("%w: %d", ErrPackagesHaveDuplicates, size)})
+		} else if err := validateSize(size, limits); err != nil {
+			errs = append(errs, fieldError{field: field, err: err})
+		}
+
+		seen[size] = true
+	}
+
+	return errs
+}
+
+// validateSize makes sure a single package size is positive and within the limits.
+func validateSize(size int, limits config.PackSizeLimits) error {
+	switch {
+	case size <= 0:
+		return fmt.Errorf("%w: %d", ErrPackageNotPositive, size)
+	case size < limits.MinSize:
+		return fmt.Errorf("%w: %d, at least %d allowed", ErrPackageTooSmall, size, limits.MinSize)
+	case limits.MaxSize > 0 && size > limits.MaxSize:
+		return fmt.Errorf("%w: %d, at most %d allowed", ErrPackageTooLarge, size, limits.MaxSize)
+	}
+
+	return nil
+}
+
+// validatePackSet makes sure sizes can replace the current package sizes. On top of validateSizes, every size needs a
+// weight and volume while parcels are limited, otherwise no calculation could split its packages into parcels.
+func (h *Handler) validatePackSet(sizes []int) []fieldError {
+	errs := validateSizes(sizes, h.conf.PackSizeLimits)
+
+	// a size that is already invalid doesn't need to be told it has no spec either.
+	reported := make(map[string]bool, len(errs))
+	for _, fe := range errs {
+		reported[fe.field] = true
+	}
+
+	for i, size := range sizes {
+		field := fmt.Sprintf("sizes[%d]", i)
+		if reported[field] {
+			continue
+		}
+
+		if err := h.validateSpec(size); err != nil {
+			errs = append(errs, fieldError{field: field, err: err})
+		}
+	}
+
+	return errs
+}
+
+// validateSpec makes sure a package size has a weight and volume if parcels are limited.
+func (h *Handler) validateSpec(size int) error {
+	if limits := h.conf.ParcelLimits; limits.Weight == 0 && limits.Volume == 0 {
+		return nil
+	}
+
+	if _, ok := h.conf.PackSpecs[size]; !ok {
+		return fmt.Errorf("%w: %d", ErrPackageSpecMissing, size)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"retask/api/model"
+	"retask/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePackSet(t *testing.T) {
+	tests := []struct {
+		sizes    []int
+		limits   config.ParcelLimits
+		expected []fieldError
+	}{
+		{sizes: []int{250, 750}},
+		{sizes: []int{250, 750}, limits: config.ParcelLimits{Weight: 31500}, expected: []fieldError{
+			{field: "sizes[1]", err: ErrPackageSpecMissing},
+		}},
+		// sizes that are invalid anyway aren't told they have no spec on top.
+		{sizes: []int{250, 0, 250}, limits: config.ParcelLimits{Volume: 100000}, expected: []fieldError{
+			{field: "sizes[1]", err: ErrPackageNotPositive},
+			{field: "sizes[2]", err: ErrPackagesHaveDuplicates},
+		}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			h := newTestHandler(&config.Config{
+				PackSpecs:    map[int]config.PackSpec{250: {Weight: 270, Volume: 1000}},
+				ParcelLimits: test.limits,
+			})
+
+			errs := h.validatePackSet(test.sizes)
+
+			if !assert.Len(t, errs, len(test.expected)) {
+				return
+			}
+			for k, expected := range test.expected {
+				assert.Equal(t, expected.field, errs[k].field)
+				assert.ErrorIs(t, errs[k].err, expected.err)
+			}
+		})
+	}
+}
+
+func TestUpdatePackageSizesViolations(t *testing.T) {
+	tests := []struct {
+		body       string
+		status     int
+		violations []model.Violation
+	}{
+		{body: `{"sizes": [250, 750]}`, status: 200},
+		// encoding/json matches field names case insensitively, so does the check for unknown fields.
+		{body: `{"Sizes": [250, 750]}`, status: 200},
+		{body: `{"sizes": []}`, status: 400, violations: []model.Violation{{Field: "sizes", Code: "no_packages"}}},
+		{body: `{"sizes": [250, 0, 250, 2000000], "size": 3}`, status: 400, violations: []model.Violation{
+			{Field: "sizes[1]", Code: "package_not_positive"},
+			{Field: "sizes[2]", Code: "packages_have_duplicates"},
+			{Field: "sizes[3]", Code: "package_too_large"},
+			{Field: "size", Code: "unknown_field"},
+		}},
+		{body: `{"sizes": [1, 2, 3, 4, 5], "other": 1, "another": 2}`, status: 400, violations: []model.Violation{
+			{Field: "sizes", Code: "too_many_packages"},
+			{Field: "another", Code: "unknown_field"},
+			{Field: "other", Code: "unknown_field"},
+		}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			conf := &config.Config{PackSizeLimits: config.PackSizeLimits{MinSize: 1, MaxSize: 1000000, MaxCount: 4}}
+			conf.SetPacks([]int{250, 500}, "test")
+			h := newTestHandler(conf)
+
+			rw := serve(h.UpdatePackageSizes, "PUT", "/pack-sizes", "/pack-sizes", test.body, "If-Match", "*")
+
+			assert.Equal(t, test.status, rw.Code)
+			if test.violations == nil {
+				return
+			}
+
+			out := &struct {
+				model.ErrorResponse
+				Details []model.Violation `json:"details"`
+			}{}
+			assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), out))
+			assert.Equal(t, test.violations[0].Code, out.Code)
+
+			// messages carry the offending values, only fields and codes are stable.
+			for k := range out.Details {
+				assert.NotEmpty(t, out.Details[k].Message)
+				out.Details[k].Message = ""
+			}
+			assert.Equal(t, test.violations, out.Details)
+		})
+	}
+}
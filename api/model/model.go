@@ -1,14 +1,273 @@
 package model
 
+import "time"
+
 // UpdatePackageSizes serves as both the Request and Response struct for UpdatePackageSizes endpoint.
 type UpdatePackageSizes struct {
 	Sizes []int `json:"sizes"`
 }
 
+// PackSizesHistory is every version of the package sizes, oldest first.
+type PackSizesHistory struct {
+	Versions []PackSizesVersion `json:"versions"`
+}
+
+// PackSizesVersion is a version of the package sizes, who changed them from what to what and when. The first version
+// is the one of config.yaml, it has no From.
+type PackSizesVersion struct {
+	Version   int       `json:"version"`
+	From      []int     `json:"from,omitempty"`
+	To        []int     `json:"to"`
+	ChangedBy string    `json:"changedBy"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// AddPackSize is the request of adding a single size to the package sizes, the response is UpdatePackageSizes.
+type AddPackSize struct {
+	Size int `json:"size"`
+}
+
+// Order quantities are int64, so they don't depend on the platform the service runs on. The packaging repo rejects
+// orders it can't count the distribution of.
+
 type CalculateBestPackagesRequest struct {
-	Order int `json:"order"`
+	Order int64 `json:"order"`
+	// IncludeAlternatives asks for the other distributions that are exactly as good as Packages.
+	IncludeAlternatives bool `json:"includeAlternatives,omitempty"`
+	// Stock optionally limits the number of packs available per size, sizes that are missing are unlimited.
+	Stock map[int]int `json:"stock,omitempty"`
+	// Strategy optionally selects a fulfilment policy by name, e.g. "exact-or-fail" or "max-overage-100".
+	Strategy string `json:"strategy,omitempty"`
+	// Objective optionally ranks distributions by an ordered list of "items", "packs" and "cost".
+	Objective []string `json:"objective,omitempty"`
+	// MaxOverage and MaxOveragePercent optionally cap the items sent above the order, in items and in percent of the
+	// order. ExactOnly only accepts the order exactly.
+	MaxOverage        *int     `json:"maxOverage,omitempty"`
+	MaxOveragePercent *float64 `json:"maxOveragePercent,omitempty"`
+	ExactOnly         bool     `json:"exactOnly,omitempty"`
+	// IncludePackages adds the legacy list of every single pack to the v2 response.
+	IncludePackages bool `json:"includePackages,omitempty"`
 }
 
 type CalculateBestPackagesResponse struct {
 	Packages []int `json:"packages"`
+	CalculationDetails
+}
+
+// CalculateBestPackagesV2Response groups the distribution by size instead of listing every single pack, so it stays
+// small no matter the order. Overage is negative if less than the order is sent.
+type CalculateBestPackagesV2Response struct {
+	Packs      []PackQuantity `json:"packs"`
+	TotalItems int64          `json:"totalItems"`
+	Overage    int64          `json:"overage"`
+	PackCount  int            `json:"packCount"`
+	// Packages is the legacy list of every single pack, it is only set if the request includes packages.
+	Packages []int `json:"packages,omitempty"`
+	CalculationDetails
+}
+
+// PackQuantity is the number of packs of a single size.
+type PackQuantity struct {
+	Size     int `json:"size"`
+	Quantity int `json:"quantity"`
+}
+
+// CalculationDetails is everything besides the distribution that every version of the calculate-best-packages
+// response holds.
+type CalculationDetails struct {
+	Alternatives [][]int      `json:"alternatives,omitempty"`
+	Explanation  *Explanation `json:"explanation,omitempty"`
+	// Shipment is the load plan of Packages, if containers are configured. Like Parcels, it lists every pack, so the v2
+	// response only has it if the request includes packages.
+	Shipment []ShipmentLoad `json:"shipment,omitempty"`
+	// Parcels splits Packages into parcels within the carrier limits, if those are configured.
+	Parcels []Parcel `json:"parcels,omitempty"`
+	// Shipped and Backordered split the order into the items sent now and the rest. They are only set by strategies
+	// that may send less than the order, such as "under-fulfil".
+	Shipped     *int64 `json:"shipped,omitempty"`
+	Backordered *int64 `json:"backordered,omitempty"`
+}
+
+// Parcel is a single parcel with its totals, weight in grams and volume in cubic centimetres.
+type Parcel struct {
+	Packages []int `json:"packages"`
+	Weight   int   `json:"weight"`
+	Volume   int   `json:"volume"`
+}
+
+// ShipmentLoad is a single container of a load plan, the innermost ones hold Packages, the others hold Contents.
+type ShipmentLoad struct {
+	Container string         `json:"container"`
+	Items     int            `json:"items"`
+	Packages  []int          `json:"packages,omitempty"`
+	Contents  []ShipmentLoad `json:"contents,omitempty"`
+}
+
+// CalculateBestPackagesBatchRequest calculates many orders under the same options at once.
+type CalculateBestPackagesBatchRequest struct {
+	Orders    []int64     `json:"orders"`
+	Stock     map[int]int `json:"stock,omitempty"`
+	Strategy  string      `json:"strategy,omitempty"`
+	Objective []string    `json:"objective,omitempty"`
+}
+
+// CalculateBestPackagesBatchResponse holds a result per order, in the order of the request.
+type CalculateBestPackagesBatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// BatchResult is the outcome of a single order of a batch, Code and Error are set instead of Packages if it failed.
+// Code is the code the order would have failed a calculate-best-packages request with.
+type BatchResult struct {
+	Order    int64  `json:"order"`
+	Packages []int  `json:"packages,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AnalyzePackSetRequest proposes a set of package sizes, to be analysed over the orders from From to To.
+type AnalyzePackSetRequest struct {
+	Sizes []int `json:"sizes"`
+	From  int64 `json:"from"`
+	To    int64 `json:"to"`
+}
+
+// AnalyzePackSetResponse tells what the proposed sizes would do compared to the current ones.
+type AnalyzePackSetResponse struct {
+	GCD int `json:"gcd"`
+	// Frobenius is the largest multiple of GCD that can't be packed exactly, -1 if there is none.
+	Frobenius       int             `json:"frobenius"`
+	MaxOverage      int             `json:"maxOverage"`
+	MaxOverageOrder int             `json:"maxOverageOrder"`
+	AverageOverage  float64         `json:"averageOverage"`
+	Changed         int             `json:"changed"`
+	Changes         []PackSetChange `json:"changes"`
+}
+
+// PackSetChange is an order that the proposed sizes pack differently.
+type PackSetChange struct {
+	Order    int   `json:"order"`
+	Current  []int `json:"current"`
+	Proposed []int `json:"proposed"`
+}
+
+// HistogramEntry is the number of times an order quantity was ordered.
+type HistogramEntry struct {
+	Quantity int `json:"quantity"`
+	Count    int `json:"count"`
+}
+
+// StartRecommendationRequest asks for the best set of at most MaxSizes pack sizes for a history of orders.
+type StartRecommendationRequest struct {
+	Histogram []HistogramEntry `json:"histogram"`
+	MaxSizes  int              `json:"maxSizes"`
+	// Candidates optionally limits the sizes to choose from, the most frequent order quantities are used otherwise.
+	Candidates []int `json:"candidates,omitempty"`
+}
+
+// RecommendationJob is the state of a recommendation running in the background.
+type RecommendationJob struct {
+	ID       string          `json:"id"`
+	Status   string          `json:"status"`
+	Created  time.Time       `json:"created"`
+	Finished *time.Time      `json:"finished,omitempty"`
+	Result   *Recommendation `json:"result,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// Recommendation is the best pack set found, with the overage and pack count it ships over the whole history.
+type Recommendation struct {
+	Sizes     []int `json:"sizes"`
+	Orders    int64 `json:"orders"`
+	Overage   int64 `json:"overage"`
+	PackCount int64 `json:"packCount"`
+	Evaluated int   `json:"evaluated"`
+}
+
+// CacheStats reports how well the table cache is doing.
+type CacheStats struct {
+	Hits    int `json:"hits"`
+	Misses  int `json:"misses"`
+	Entries int `json:"entries"`
+	Bytes   int `json:"bytes"`
+}
+
+// Explanation tells why Packages was chosen over the next best candidates.
+type Explanation struct {
+	// Objective is the ranking that was applied, tiebreakers included.
+	Objective []string            `json:"objective"`
+	Items     int                 `json:"items"`
+	Overage   int                 `json:"overage"`
+	PackCount int                 `json:"packCount"`
+	Cost      int                 `json:"cost,omitempty"`
+	Rejected  []RejectedCandidate `json:"rejected"`
+}
+
+// RejectedCandidate is a runner-up distribution together with the criterion it lost on.
+type RejectedCandidate struct {
+	Packages  []int  `json:"packages"`
+	Items     int    `json:"items"`
+	Overage   int    `json:"overage"`
+	PackCount int    `json:"packCount"`
+	Cost      int    `json:"cost,omitempty"`
+	DecidedBy string `json:"decidedBy"`
+	// Rule is the README rule behind DecidedBy, 2 for items and 3 for packs.
+	Rule int `json:"rule,omitempty"`
+}
+
+// ErrorResponse is the body of every error, Code is stable for clients to handle the error programmatically.
+type ErrorResponse struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"`
+	// Details carries whatever else helps to handle the error, e.g. Nearest if the overage of an order can't be capped
+	// as requested, or every Violation of an invalid request.
+	Details any `json:"details,omitempty"`
+}
+
+// Violation is a single reason a request is invalid, Field is the json path of the offending value, e.g. "sizes[2]".
+type Violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Nearest are the distributions closest to an order that could be sent instead, below and above it.
+type Nearest struct {
+	Under []int `json:"under,omitempty"`
+	Over  []int `json:"over,omitempty"`
+}
+
+// OrderLine is a single product of a multi-SKU order.
+type OrderLine struct {
+	SKU      string `json:"sku"`
+	Quantity int64  `json:"quantity"`
+}
+
+type CalculateOrderPackagesRequest struct {
+	Lines []OrderLine `json:"lines"`
+	// Strategy optionally selects a fulfilment policy for every line, see CalculateBestPackagesRequest.
+	Strategy string `json:"strategy,omitempty"`
+}
+
+// OrderLinePackages is the packing of a single order line.
+type OrderLinePackages struct {
+	SKU       string `json:"sku"`
+	Quantity  int64  `json:"quantity"`
+	Packages  []int  `json:"packages"`
+	Items     int64  `json:"items"`
+	PackCount int    `json:"packCount"`
+}
+
+// OrderTotals sums up the packings of all lines of an order.
+type OrderTotals struct {
+	Quantity  int64 `json:"quantity"`
+	Items     int64 `json:"items"`
+	Overage   int64 `json:"overage"`
+	PackCount int   `json:"packCount"`
+}
+
+type CalculateOrderPackagesResponse struct {
+	Lines  []OrderLinePackages `json:"lines"`
+	Totals OrderTotals         `json:"totals"`
 }
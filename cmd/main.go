@@ -1,18 +1,36 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"retask/api/handler"
 	"retask/config"
 	"retask/internal/packing"
+	"retask/internal/recommend"
 	"retask/server"
+	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
+	// the recommend subcommand runs a recommendation in the foreground and exits, without config or server.
+	if len(os.Args) > 1 && os.Args[1] == "recommend" {
+		if err := runRecommend(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	conf, err := config.New()
 	if err != nil {
 		logrus.Fatal(err)
@@ -20,8 +38,24 @@ func main() {
 
 	logger := logrus.WithField("method", "main")
 
-	packingRepo := packing.New()
-	h := handler.New(conf, packingRepo)
+	// fail early on a typo in the config rather than on every request.
+	if _, err := packing.LookupStrategy(conf.Strategy); err != nil {
+		logger.WithField("error", err).Fatal("failed to find configured strategy")
+	}
+
+	// tables only depend on the pack set, so they are cached until the sizes change.
+	cache := packing.NewCache(conf.CacheSize)
+	conf.OnPacksChange(cache.Purge)
+
+	packingRepo := packing.NewCached(cache)
+	// recommendations pack the same orders with ever different pack sets, their tables would only evict those of
+	// requests, so they get a cache of their own.
+	recommendRepo := recommend.New(packing.NewCached(packing.NewCache(conf.RecommendCacheSize)), recommend.Limits{
+		MaxPacks:      conf.MaxPacks,
+		MaxTableBytes: conf.MaxTableSize,
+	})
+	jobs := recommend.NewJobs(recommendRepo, conf.RecommendTimeout, conf.RecommendJobs)
+	h := handler.New(conf, packingRepo, cache, jobs)
 	serv, err := server.New(conf, h)
 	if err != nil {
 		logger.WithField("error", err).Fatal("failed to init server")
@@ -30,13 +64,14 @@ func main() {
 	serv.ListenAndServe(logger)
 
 	// This allows us to listen for interrupts (ctrl+c, shutting down the run in goland/vscode, etc)
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	<-c
 
 	logger.Info("signal interrupt detected, shutting down ...")
 
 	// shutdown the server
+	jobs.Close()
 	if err := serv.Shutdown(); err != nil {
 		logger.Fatalf("failed to shutdown server with error: %v", err)
 	}
@@ -45,3 +80,53 @@ func main() {
 	os.Exit(1)
 
 }
+
+// runRecommend reads a histogram of orders from a csv or json file and prints the recommended pack sizes.
+// Usage: re-task recommend -histogram orders.csv -max-sizes 5 [-candidates 250,500,1000]
+func runRecommend(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("recommend", flag.ContinueOnError)
+	path := flags.String("histogram", "", "csv of quantity,count lines or json array of {quantity, count}")
+	maxSizes := flags.Int("max-sizes", 5, "largest number of pack sizes to recommend")
+	candidates := flags.String("candidates", "", "comma separated sizes to choose from, frequent quantities if empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var histogram recommend.Histogram
+	if strings.EqualFold(filepath.Ext(*path), ".json") {
+		histogram, err = recommend.ParseJSON(f)
+	} else {
+		histogram, err = recommend.ParseCSV(f)
+	}
+	if err != nil {
+		return err
+	}
+
+	opts := recommend.Options{MaxSizes: *maxSizes}
+	for _, field := range strings.FieldsFunc(*candidates, func(r rune) bool { return r == ',' }) {
+		size, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return fmt.Errorf("invalid candidate %q: %w", field, err)
+		}
+
+		opts.Candidates = append(opts.Candidates, size)
+	}
+
+	// the command runs on its own, so it is as limited as the machine it runs on.
+	recommender := recommend.New(packing.NewCached(packing.NewCache(64<<20)), recommend.Limits{})
+	rec, err := recommender.Recommend(context.Background(), histogram, opts)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(out, "sizes: %v\norders: %d\noverage: %d\npack count: %d\nevaluated: %d\n",
+		rec.Sizes, rec.Orders, rec.Overage, rec.PackCount, rec.Evaluated)
+
+	return err
+}
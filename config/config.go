@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"slices"
 	"sort"
 	"sync"
 	"time"
@@ -14,11 +15,81 @@ import (
 
 type Config struct {
 	lock        sync.Mutex
-	logType     string        // required internally by config
-	logLevel    string        // required internally by config
-	packs       []int         // will have get/set due to mutex
-	ServerPort  int           // free to access by server, only required in setup
-	HttpTimeout time.Duration // free to access by server, only required in setup
+	logType     string           // required internally by config
+	logLevel    string           // required internally by config
+	packs       []int            // will have get/set due to mutex
+	version     int              // version of packs, bumped whenever they change
+	history     []PackSetVersion // the latest versions of packs, oldest first, see PacksHistorySize
+	onPacks     []func()         // called whenever SetPacks changes the sizes
+	ServerPort  int              // free to access by server, only required in setup
+	HttpTimeout time.Duration    // free to access by server, only required in setup
+
+	MaxAlternatives int         // upper bound of equally good distributions returned per request, read only
+	MaxBatchSize    int         // upper bound of orders calculated per batch request, read only
+	MaxPacks        int         // upper bound of packs in a single distribution, read only
+	MaxTableSize    int         // upper bound of memory taken by a single table in bytes, read only
+	CacheSize       int         // upper bound of memory held by cached tables in bytes, read only
+	PackCosts       map[int]int // cost of a single pack per size, used by the cost objective, read only
+	Strategy        string      // name of the fulfilment strategy used when a request doesn't pick one, read only
+
+	Catalogues map[string][]int // pack sizes per SKU, sorted, read only
+	Containers []Container      // levels of the load plan, innermost first, read only
+
+	PackSpecs    map[int]PackSpec // weight and volume of a single pack per size, read only
+	ParcelLimits ParcelLimits     // caps every parcel of a calculation, nothing is capped if zero, read only
+
+	PackSizeLimits   PackSizeLimits // bounds the pack sizes updated at runtime, nothing is bounded if zero, read only
+	PacksHistorySize int            // upper bound of versions of packs kept, all are kept if zero, read only
+
+	RecommendTimeout       time.Duration // upper bound of time a recommendation job may run, read only
+	RecommendJobs          int           // upper bound of recommendation jobs running at once, read only
+	RecommendMaxSizes      int           // upper bound of sizes a recommendation may ask for, read only
+	RecommendMaxCandidates int           // upper bound of candidate sizes a recommendation may be given, read only
+	RecommendCacheSize     int           // upper bound of memory held by the tables cached for recommendations, read only
+}
+
+// catalogue is the config.yaml representation of the pack sizes of a single SKU. SKUs are kept as values rather than
+// keys, since viper lowercases keys.
+type catalogue struct {
+	SKU   string `mapstructure:"sku"`
+	Packs []int  `mapstructure:"packs"`
+}
+
+// Container is a level of the load plan, e.g. a carton holding packs or a pallet holding cartons. The innermost
+// container's capacity is in items, the others hold that many containers of the level below.
+type Container struct {
+	Name     string `mapstructure:"name"`
+	Capacity int    `mapstructure:"capacity"`
+}
+
+// PackSpec is the weight (in grams) and volume (in cubic centimetres) of a single pack.
+type PackSpec struct {
+	Weight int `mapstructure:"weight"`
+	Volume int `mapstructure:"volume"`
+}
+
+// ParcelLimits are the weight and volume limits of a single parcel set by the carrier, 0 doesn't limit anything.
+type ParcelLimits struct {
+	Weight int `mapstructure:"weight"`
+	Volume int `mapstructure:"volume"`
+}
+
+// PackSizeLimits bound the sizes and the number of sizes a pack set may be updated to, 0 doesn't limit anything. Sizes
+// are always positive, no matter the limits.
+type PackSizeLimits struct {
+	MinSize  int `mapstructure:"minSize"`
+	MaxSize  int `mapstructure:"maxSize"`
+	MaxCount int `mapstructure:"maxCount"`
+}
+
+// PackSetVersion is a version of the pack set, together with who changed it from what to Packs and when. Versions are
+// never changed once they are recorded, the first one is the pack set of config.yaml.
+type PackSetVersion struct {
+	Version   int
+	Packs     []int
+	Previous  []int
+	ChangedBy string
+	ChangedAt time.Time
 }
 
 func New() (*Config, error) {
@@ -39,14 +110,127 @@ func New() (*Config, error) {
 		return nil, errors.Wrap(err, "failed to parse http request timeout duration")
 	}
 
+	var packCosts map[int]int
+	if err := viper.UnmarshalKey("packCosts", &packCosts); err != nil {
+		return nil, errors.Wrap(err, "failed to parse pack costs")
+	}
+
+	var catalogueList []catalogue
+	if err := viper.UnmarshalKey("catalogues", &catalogueList); err != nil {
+		return nil, errors.Wrap(err, "failed to parse catalogues")
+	}
+
+	catalogues := make(map[string][]int, len(catalogueList))
+	for _, c := range catalogueList {
+		if _, ok := catalogues[c.SKU]; ok {
+			return nil, fmt.Errorf("duplicate catalogue for sku: %s", c.SKU)
+		}
+
+		if len(c.Packs) == 0 {
+			return nil, fmt.Errorf("catalogue for sku %s has no packs", c.SKU)
+		}
+
+		// sorted once here, so concurrent calculations never have to sort a shared slice.
+		sort.Ints(c.Packs)
+		catalogues[c.SKU] = c.Packs
+	}
+
+	var containers []Container
+	if err := viper.UnmarshalKey("containers", &containers); err != nil {
+		return nil, errors.Wrap(err, "failed to parse containers")
+	}
+
+	for _, c := range containers {
+		if c.Name == "" || c.Capacity <= 0 {
+			return nil, fmt.Errorf("container %q needs a name and a positive capacity", c.Name)
+		}
+	}
+
+	var packSpecs map[int]PackSpec
+	if err := viper.UnmarshalKey("packSpecs", &packSpecs); err != nil {
+		return nil, errors.Wrap(err, "failed to parse pack specs")
+	}
+
+	var parcelLimits ParcelLimits
+	if err := viper.UnmarshalKey("parcelLimits", &parcelLimits); err != nil {
+		return nil, errors.Wrap(err, "failed to parse parcel limits")
+	}
+
+	if parcelLimits.Weight < 0 || parcelLimits.Volume < 0 {
+		return nil, fmt.Errorf("parcel limits can't be negative")
+	}
+
+	// limited parcels weigh every pack, so every size needs a spec.
+	if parcelLimits.Weight > 0 || parcelLimits.Volume > 0 {
+		for _, pack := range viper.GetIntSlice("packs") {
+			if _, ok := packSpecs[pack]; !ok {
+				return nil, fmt.Errorf("pack size %d has no pack spec, which limited parcels require", pack)
+			}
+		}
+	}
+
+	var packSizeLimits PackSizeLimits
+	if err := viper.UnmarshalKey("packSizeLimits", &packSizeLimits); err != nil {
+		return nil, errors.Wrap(err, "failed to parse pack size limits")
+	}
+
+	if packSizeLimits.MinSize < 0 || packSizeLimits.MaxSize < 0 || packSizeLimits.MaxCount < 0 {
+		return nil, fmt.Errorf("pack size limits can't be negative")
+	}
+
+	if packSizeLimits.MaxSize > 0 && packSizeLimits.MinSize > packSizeLimits.MaxSize {
+		return nil, fmt.Errorf("pack size limits can't have a min size above the max size")
+	}
+
+	recommendTimeout, err := time.ParseDuration(fmt.Sprintf("%ds", viper.GetInt("recommendTimeout")))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse recommendation timeout duration")
+	}
+
+	// GetPacks hands out the current set itself and calculations sort the set they get in place, so it has to be sorted
+	// from the start, which leaves it untouched, and version 1 gets a copy of its own.
+	packs := slices.Clone(viper.GetIntSlice("packs"))
+	sort.Ints(packs)
+
 	conf := &Config{
 		logLevel:    viper.GetString("logLevel"),
 		logType:     viper.GetString("logType"),
-		packs:       viper.GetIntSlice("packs"),
+		packs:       packs,
+		version:     1,
 		ServerPort:  viper.GetInt("serverPort"),
 		HttpTimeout: httpTimeoutDuration,
+
+		MaxAlternatives: viper.GetInt("maxAlternatives"),
+		MaxBatchSize:    viper.GetInt("maxBatchSize"),
+		MaxPacks:        viper.GetInt("maxPacks"),
+		MaxTableSize:    viper.GetInt("maxTableSize") << 20,
+		CacheSize:       viper.GetInt("cacheSize") << 20,
+		PackCosts:       packCosts,
+		Strategy:        viper.GetString("strategy"),
+
+		Catalogues: catalogues,
+		Containers: containers,
+
+		PackSpecs:    packSpecs,
+		ParcelLimits: parcelLimits,
+
+		PackSizeLimits:   packSizeLimits,
+		PacksHistorySize: viper.GetInt("packsHistorySize"),
+
+		RecommendTimeout:       recommendTimeout,
+		RecommendJobs:          viper.GetInt("recommendJobs"),
+		RecommendMaxSizes:      viper.GetInt("recommendMaxSizes"),
+		RecommendMaxCandidates: viper.GetInt("recommendMaxCandidates"),
+		RecommendCacheSize:     viper.GetInt("recommendCacheSize") << 20,
 	}
 
+	conf.history = []PackSetVersion{{
+		Version:   conf.version,
+		Packs:     slices.Clone(conf.packs),
+		ChangedBy: "config",
+		ChangedAt: time.Now().UTC(),
+	}}
+
 	if err := conf.initLogger(); err != nil {
 		return nil, errors.Wrap(err, "failed to init logger")
 	}
@@ -57,6 +241,29 @@ func New() (*Config, error) {
 		"packs":       conf.packs,
 		"serverPort":  conf.ServerPort,
 		"httpTimeout": conf.HttpTimeout,
+
+		"maxAlternatives": conf.MaxAlternatives,
+		"maxBatchSize":    conf.MaxBatchSize,
+		"maxPacks":        conf.MaxPacks,
+		"maxTableSize":    conf.MaxTableSize,
+		"cacheSize":       conf.CacheSize,
+		"packCosts":       conf.PackCosts,
+		"strategy":        conf.Strategy,
+
+		"catalogues": conf.Catalogues,
+		"containers": conf.Containers,
+
+		"packSpecs":    conf.PackSpecs,
+		"parcelLimits": conf.ParcelLimits,
+
+		"packSizeLimits":   conf.PackSizeLimits,
+		"packsHistorySize": conf.PacksHistorySize,
+
+		"recommendTimeout":       conf.RecommendTimeout,
+		"recommendJobs":          conf.RecommendJobs,
+		"recommendMaxSizes":      conf.RecommendMaxSizes,
+		"recommendMaxCandidates": conf.RecommendMaxCandidates,
+		"recommendCacheSize":     conf.RecommendCacheSize,
 	}).Info("parsed config")
 
 	return conf, nil
@@ -91,14 +298,67 @@ func (c *Config) initLogger() error {
 }
 
 // SetPacks takes a slice of ints, that represent our package sizes. It locks the config to overwrite the current set.
-func (c *Config) SetPacks(packs []int) []int {
+// Listeners registered with OnPacksChange are called once the lock is released, if the sizes actually changed.
+func (c *Config) SetPacks(packs []int, changedBy string) []int {
+	packs, _, _ = c.UpdatePacks(changedBy, func([]int, int) ([]int, error) {
+		return packs, nil
+	})
+
+	return packs
+}
+
+// UpdatePacks replaces the current set of packs with the one fn returns, unless fn fails, and returns it together with
+// its version. The config stays locked while fn runs, so changes based on the current set, e.g. adding a single size or
+// only changing a version that wasn't changed by anyone else, never overwrite each other. fn gets a copy of the current
+// set it is free to change and its version. If the sizes actually change, they are recorded as a new version changed
+// by changedBy, and listeners are called like for SetPacks.
+func (c *Config) UpdatePacks(changedBy string, fn func(packs []int, version int) ([]int, error)) ([]int, int, error) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
 
-	c.packs = packs
-	sort.Ints(c.packs)
+	packs, err := fn(slices.Clone(c.packs), c.version)
+	if err != nil {
+		c.lock.Unlock()
+		return nil, 0, err
+	}
 
-	return c.packs
+	sort.Ints(packs)
+	changed := !slices.Equal(c.packs, packs)
+	if changed {
+		c.history = append(c.history, PackSetVersion{
+			Version:   c.version + 1,
+			Packs:     packs,
+			Previous:  c.packs,
+			ChangedBy: changedBy,
+			ChangedAt: time.Now().UTC(),
+		})
+		c.packs = packs
+		c.version++
+
+		if n := c.PacksHistorySize; n > 0 && len(c.history) > n {
+			c.history = slices.Delete(c.history, 0, len(c.history)-n)
+		}
+	}
+	packs, version := c.packs, c.version
+	listeners := c.onPacks
+
+	c.lock.Unlock()
+
+	if changed {
+		for _, fn := range listeners {
+			fn()
+		}
+	}
+
+	return packs, version, nil
+}
+
+// OnPacksChange registers fn to be called whenever SetPacks changes the set of packs, e.g. to drop caches built for
+// the previous set.
+func (c *Config) OnPacksChange(fn func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.onPacks = append(c.onPacks, fn)
 }
 
 // GetPacks returns the current set of packs.
@@ -108,3 +368,38 @@ func (c *Config) GetPacks() []int {
 
 	return c.packs
 }
+
+// GetVersionedPacks returns the current set of packs together with its version.
+func (c *Config) GetVersionedPacks() ([]int, int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.packs, c.version
+}
+
+// GetPacksHistory returns every version of the set of packs, oldest first.
+func (c *Config) GetPacksHistory() []PackSetVersion {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return slices.Clone(c.history)
+}
+
+// GetPacksVersion returns a single version of the set of packs, false if there is no such version or it was dropped
+// from the history.
+func (c *Config) GetPacksVersion(version int) (PackSetVersion, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.history) == 0 {
+		return PackSetVersion{}, false
+	}
+
+	// versions are consecutive, so the oldest one kept tells where any other one is.
+	i := version - c.history[0].Version
+	if i < 0 || i >= len(c.history) {
+		return PackSetVersion{}, false
+	}
+
+	return c.history[i], true
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdatePacks(t *testing.T) {
+	failed := fmt.Errorf("failed")
+
+	tests := []struct {
+		updates     [][]int // sizes fn returns, one update each
+		err         error   // returned by fn of the last update
+		historySize int
+		packs       []int
+		version     int
+		versions    []int // versions kept in the history
+		calls       int   // of the listener
+	}{
+		{updates: [][]int{{500, 250, 1000}}, packs: []int{250, 500, 1000}, version: 2, versions: []int{1, 2}, calls: 1},
+		// the same sizes in another order are no change.
+		{updates: [][]int{{500, 250}}, packs: []int{250, 500}, version: 1, versions: []int{1}},
+		{updates: [][]int{{23}, {31}, {250, 500}}, packs: []int{250, 500}, version: 4, versions: []int{1, 2, 3, 4},
+			calls: 3},
+		{updates: [][]int{{23}, {31}}, err: failed, packs: []int{23}, version: 2, versions: []int{1, 2}, calls: 1},
+		{updates: [][]int{{23}, {31}, {53}}, historySize: 2, packs: []int{53}, version: 4, versions: []int{3, 4},
+			calls: 3},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			c := &Config{PacksHistorySize: test.historySize}
+			c.SetPacks([]int{250, 500}, "config")
+
+			calls := 0
+			c.OnPacksChange(func() { calls++ })
+
+			var packs []int
+			var version int
+			var err error
+			for k, update := range test.updates {
+				packs, version, err = c.UpdatePacks("alice", func(current []int, _ int) ([]int, error) {
+					// fn is free to change its copy, even if it fails.
+					for j := range current {
+						current[j] = 0
+					}
+
+					if k == len(test.updates)-1 && test.err != nil {
+						return nil, test.err
+					}
+
+					return update, nil
+				})
+			}
+
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.packs, packs)
+				assert.Equal(t, test.version, version)
+			}
+
+			current, currentVersion := c.GetVersionedPacks()
+			assert.Equal(t, test.packs, current)
+			assert.Equal(t, test.version, currentVersion)
+			assert.Equal(t, test.calls, calls)
+
+			history := c.GetPacksHistory()
+			versions := make([]int, 0, len(history))
+			for k, v := range history {
+				versions = append(versions, v.Version)
+				if k > 0 {
+					// every version changes the sizes of the one before it.
+					assert.Equal(t, history[k-1].Packs, v.Previous)
+					assert.Equal(t, "alice", v.ChangedBy)
+				}
+			}
+			assert.Equal(t, test.versions, versions)
+			assert.Equal(t, test.packs, history[len(history)-1].Packs)
+		})
+	}
+}
+
+func TestGetPacksVersion(t *testing.T) {
+	c := &Config{PacksHistorySize: 2}
+	c.SetPacks([]int{250, 500}, "config")
+	c.SetPacks([]int{23}, "alice")
+	c.SetPacks([]int{31}, "bob")
+
+	for _, test := range []struct {
+		version int
+		packs   []int
+		ok      bool
+	}{
+		// version 1 was dropped from the history.
+		{version: 1},
+		{version: 2, packs: []int{23}, ok: true},
+		{version: 3, packs: []int{31}, ok: true},
+		{version: 4},
+		{version: 0},
+	} {
+		v, ok := c.GetPacksVersion(test.version)
+		assert.Equal(t, test.ok, ok, "version %d", test.version)
+		assert.Equal(t, test.packs, v.Packs, "version %d", test.version)
+	}
+}
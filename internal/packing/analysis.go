@@ -0,0 +1,246 @@
+package packing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"unsafe"
+)
+
+// maxAnalysisCells is the largest table an analysis tabulates, unless the calculation allows even less. Pack sets that
+// need more are answered by solve alone.
+const maxAnalysisCells = 1 << 20
+
+// ErrAnalysisTooLarge is returned when a pack set is too large to be analysed.
+var ErrAnalysisTooLarge = fmt.Errorf("pack set is too large to analyse")
+
+// Analysis is what is known about a pack set under rules 1 to 3, regardless of the order.
+//
+// Above some bound, the best distribution of an order is the best distribution of the order a largest pack smaller,
+// plus that pack. Every large order therefore falls back onto one of a largest pack's worth of remainders, which are
+// solved once per pack set, and is answered by adding largest packs to that remainder's distribution.
+type Analysis struct {
+	// Divisor is the greatest common divisor of the sizes, only its multiples can be sent.
+	Divisor int
+	// Frobenius is the largest multiple of Divisor that no combination of packs adds up to, or -1 if there is none.
+	Frobenius int
+	// Period is the largest pack size, answers above Bound repeat with it.
+	Period int
+	// Bound is the largest order that isn't answered from the remainders.
+	Bound int
+
+	period     int     // largest reduced pack
+	from       int     // smallest reduced order answered from the remainders
+	remainders [][]int // best distribution of every reduced order from from-period up to from
+}
+
+// Analyse analyses packs under rules 1 to 3.
+func (p *Packager) Analyse(ctx context.Context, packs []int) (*Analysis, error) {
+	sort.Ints(packs)
+
+	return p.cache.analysis(ctx, packs, defaultTableCells)
+}
+
+// analyse analyses packs, which must be sorted in ascending order. The table is taken from cache, which may be nil, and
+// holds at most cells totals.
+//
+// setAside already makes every order above the reduction bound plus a largest pack periodic, but the bound is derived
+// from the sizes alone and is usually far from tight. So we read the orders up to there off the table and walk down
+// for as long as an order keeps being the one a largest pack below plus that pack.
+func analyse(ctx context.Context, cache *Cache, packs []int, cells int) (*Analysis, error) {
+	reduced, divisor := normalise(packs)
+	objective := minItemsThenMinPacks.objective.normalise()
+	weights, err := objective.weights(reduced, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	d := len(reduced) - 1
+	largest := reduced[d]
+	bound := reductionBound(reduced, d)
+	top := bound + largest
+	if top+largest > min(maxAnalysisCells, cells) {
+		return nil, ErrAnalysisTooLarge
+	}
+
+	t, err := cache.table(ctx, reduced, weights, objective, top+largest-1)
+	if err != nil {
+		return nil, err
+	}
+
+	// with items first, the best total of an order is the closest reachable one, see table.best.
+	answers := make([]int, top+1)
+	for i := 1; i <= top; i++ {
+		answers[i] = t.best(i, i+largest-1, false)
+	}
+
+	// shifted[x] tells whether the walk back from x adds up to the walk back from x-largest plus a largest pack. That is
+	// the case if the walk starts with a largest pack, or if both walks start with the same pack and continue from
+	// totals that are shifted in turn.
+	shifted := make([]bool, answers[top]+1)
+	for x := largest; x < len(shifted); x++ {
+		if !t.reachable(x) || !t.reachable(x-largest) {
+			continue
+		}
+
+		j := t.last[x]
+		shifted[x] = j == d || (j == t.last[x-largest] && x-reduced[j] >= largest && shifted[x-reduced[j]])
+	}
+
+	// walking down from the top, an order is periodic for as long as its answer is the one a largest pack below, shifted.
+	from := top + 1
+	for i := from - 1; i-largest >= 1; i-- {
+		if answers[i] != answers[i-largest]+largest || !shifted[answers[i]] {
+			break
+		}
+
+		from = i
+	}
+
+	remainders := make([][]int, largest)
+	for r := range remainders {
+		remainders[r] = t.counts(answers[from-largest+r])
+	}
+
+	// reachability is periodic above the reduction bound as well, so the largest unreachable total can't be above it.
+	frobenius := -1
+	for i := bound; i > 0; i-- {
+		if !t.reachable(i) {
+			frobenius = i * divisor
+			break
+		}
+	}
+
+	return &Analysis{
+		Divisor:    divisor,
+		Frobenius:  frobenius,
+		Period:     largest * divisor,
+		Bound:      (from - 1) * divisor,
+		period:     largest,
+		from:       from,
+		remainders: remainders,
+	}, nil
+}
+
+// analyseGaps only finds the divisor and the Frobenius number of packs, which must be sorted in ascending order, for
+// pack sets too large for analyse. Period and Bound are left at 0, and the analysis answers no order.
+func analyseGaps(ctx context.Context, packs []int, cells int) (*Analysis, error) {
+	reduced, divisor := normalise(packs)
+	frobenius, err := frobeniusOf(ctx, reduced, cells)
+	if err != nil {
+		return nil, err
+	}
+
+	if frobenius > 0 {
+		frobenius *= divisor
+	}
+
+	return &Analysis{Divisor: divisor, Frobenius: frobenius}, nil
+}
+
+// frobeniusOf returns the largest total that no combination of reduced, sorted in ascending order and without a common
+// divisor, adds up to, or -1 if there is none. It holds one total per remainder of the smallest pack, at most cells.
+//
+// dist[r] becomes the smallest reachable total that leaves remainder r, every total above it with the same remainder is
+// reached by adding smallest packs. The sizes are added one at a time, each walks the cycles it makes through the
+// remainders from their smallest total, so every size takes a single pass over them.
+func frobeniusOf(ctx context.Context, reduced []int, cells int) (int, error) {
+	smallest := reduced[0]
+	if smallest == 1 {
+		return -1, nil
+	}
+	if smallest > cells {
+		return 0, ErrAnalysisTooLarge
+	}
+
+	dist := make([]int, smallest)
+	for r := 1; r < smallest; r++ {
+		dist[r] = math.MaxInt
+	}
+
+	for _, pack := range reduced[1:] {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		// adding pack walks the remainders in cycles through the ones congruent modulo g.
+		g := gcd(smallest, pack)
+		for start := 0; start < g; start++ {
+			q := start
+			for r := start + g; r < smallest; r += g {
+				if dist[r] < dist[q] {
+					q = r
+				}
+			}
+			if dist[q] == math.MaxInt {
+				continue
+			}
+
+			for i := 0; i < smallest/g; i++ {
+				next := (q + pack) % smallest
+				dist[next] = min(dist[next], dist[q]+pack)
+				q = next
+			}
+		}
+	}
+
+	// without a common divisor every remainder is reached, and the largest one is never reached by itself.
+	return slices.Max(dist) - smallest, nil
+}
+
+// counts returns the best distribution of the reduced order, or false if the order is not above the bound.
+func (a *Analysis) counts(order int) ([]int, bool) {
+	if order < a.from {
+		return nil, false
+	}
+
+	base := a.from - a.period
+	k := (order - base) / a.period
+	out := slices.Clone(a.remainders[order-base-k*a.period])
+	out[len(out)-1] += k
+
+	return out, true
+}
+
+// size returns the approximate number of bytes the analysis holds on to.
+func (a *Analysis) size() int {
+	size := int(unsafe.Sizeof(*a))
+	for _, r := range a.remainders {
+		size += len(r) * int(unsafe.Sizeof(0))
+	}
+
+	return size
+}
+
+// periodic answers a large order from the analysis of the pack set. It only applies to orders that are ranked by rules
+// 1 to 3 with the window open at the top, and only when a cache keeps the analysis around, lo is the bottom of the
+// reduced window.
+func (p *Packager) periodic(ctx context.Context, pr *problem, target, lo int) ([]int, bool) {
+	if p.cache == nil || pr.opts.Alternatives > 0 || pr.opts.Explain || pr.capped() ||
+		!slices.Equal(pr.objective, minItemsThenMinPacks.objective.normalise()) {
+		return nil, false
+	}
+
+	if wlo, whi := pr.strategy.Window(target); wlo != target || whi != math.MaxInt {
+		return nil, false
+	}
+
+	// below the reduction bound the table is small anyway, so only large orders are worth analysing the set for.
+	if lo <= reductionBound(pr.reduced, len(pr.reduced)-1) {
+		return nil, false
+	}
+
+	a, err := p.cache.analysis(ctx, pr.packs, pr.opts.tableCells())
+	if err != nil {
+		return nil, false
+	}
+
+	counts, ok := a.counts(lo)
+	if !ok || !fits(counts, pr.stock) {
+		return nil, false
+	}
+
+	return counts, true
+}
@@ -0,0 +1,112 @@
+package packing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyse(t *testing.T) {
+	tests := []struct {
+		packs     []int
+		divisor   int
+		frobenius int
+		period    int
+	}{
+		{packs: packs, divisor: 250, frobenius: -1, period: 5000},
+		{packs: []int{6, 9, 20}, divisor: 1, frobenius: 43, period: 20},
+		{packs: []int{3, 4, 5}, divisor: 1, frobenius: 2, period: 5},
+		{packs: []int{10, 15}, divisor: 5, frobenius: 5, period: 15},
+		{packs: []int{7}, divisor: 7, frobenius: -1, period: 7},
+	}
+
+	repo := New()
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			out, err := repo.Analyse(context.Background(), test.packs)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.divisor, out.Divisor)
+			assert.Equal(t, test.frobenius, out.Frobenius)
+			assert.Equal(t, test.period, out.Period)
+
+			// the measured bound is never looser than the one setAside works with.
+			reduced, divisor := normalise(test.packs)
+			assert.LessOrEqual(t, out.Bound, (reductionBound(reduced, len(reduced)-1)+reduced[len(reduced)-1])*divisor)
+		})
+	}
+}
+
+func TestAnalyseTooLarge(t *testing.T) {
+	_, err := New().Analyse(context.Background(), []int{99991, 99989})
+	assert.ErrorIs(t, err, ErrAnalysisTooLarge)
+}
+
+func TestAnalyseGaps(t *testing.T) {
+	tests := []struct {
+		packs     []int
+		divisor   int
+		frobenius int
+	}{
+		{packs: packs, divisor: 250, frobenius: -1},
+		{packs: []int{6, 9, 20}, divisor: 1, frobenius: 43},
+		{packs: []int{3, 4, 5}, divisor: 1, frobenius: 2},
+		{packs: []int{10, 15}, divisor: 5, frobenius: 5},
+		{packs: []int{7}, divisor: 7, frobenius: -1},
+		{packs: []int{23, 31, 53}, divisor: 1, frobenius: 326},
+		// too large for analyse, two coprime sizes leave a*b-a-b.
+		{packs: []int{99989, 99991}, divisor: 1, frobenius: 99989*99991 - 99989 - 99991},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			out, err := analyseGaps(context.Background(), test.packs, defaultTableCells)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.divisor, out.Divisor)
+			assert.Equal(t, test.frobenius, out.Frobenius)
+			assert.Zero(t, out.Period)
+		})
+	}
+
+	_, err := analyseGaps(context.Background(), []int{99989, 99991}, 1000)
+	assert.ErrorIs(t, err, ErrAnalysisTooLarge)
+}
+
+func TestPeriodicMatchesSolve(t *testing.T) {
+	sets := [][]int{packs, packsNonDivisible, {23, 31, 53}, {3, 4, 5}, {6, 9, 20}, {1, 2, 3}}
+	for i, set := range sets {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			cached := NewCached(NewCache(1 << 24))
+			a, err := cached.Analyse(context.Background(), set)
+			assert.NoError(t, err)
+
+			// every order from below the bound up to past the point setAside starts from, plus a few large ones.
+			reduced, divisor := normalise(set)
+			top := (reductionBound(reduced, len(reduced)-1) + 3*reduced[len(reduced)-1]) * divisor
+			orders := []int{100_003, 1_000_001}
+			for order := max(1, a.Bound-3*a.Period); order <= top; order += divisor {
+				orders = append(orders, order)
+			}
+
+			for _, order := range orders {
+				expected, err := New().Solve(context.Background(), set, order, Options{})
+				assert.NoError(t, err)
+
+				out, err := cached.Solve(context.Background(), set, order, Options{})
+				assert.NoError(t, err)
+				assert.Equal(t, expected, out, "order %d", order)
+
+				// above the bound, the remainders alone must give the same answer.
+				if counts, ok := a.counts((order + divisor - 1) / divisor); ok {
+					assert.Greater(t, order, a.Bound)
+					assert.Equal(t, expected.Packages, remap(counts, set), "order %d", order)
+				} else {
+					assert.LessOrEqual(t, order, a.Bound)
+				}
+			}
+		})
+	}
+}
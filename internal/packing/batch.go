@@ -0,0 +1,95 @@
+package packing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// BatchResult is the outcome of a single order of a batch. Exactly one of Result and Err is set.
+type BatchResult struct {
+	Result *Result
+	Err    error
+}
+
+// SolveBatch calculates the best distribution of packs for every target under the same options. It returns the
+// results in the order of targets, an order that can't be fulfilled only fails its own result.
+//
+// Rows of the table don't depend on how far it goes, so the table tabulated for the largest order already answers every
+// smaller one. Every order sets aside its own bulk, so the shared table never grows past what the largest remainder
+// needs. The batch as a whole only fails if the options are invalid or the context is done.
+func (p *Packager) SolveBatch(ctx context.Context, packs []int, targets []int, opts Options) ([]BatchResult, error) {
+	sort.Ints(packs)
+
+	pr, err := newProblem(packs, opts)
+	if errors.Is(err, ErrInsufficientStock) && below(opts.Strategy) {
+		// like Solve, every order is sent as nothing at all.
+		out := make([]BatchResult, len(targets))
+		for i := range out {
+			out[i].Result = &Result{Packages: []int{}, Packs: []PackQuantity{}}
+		}
+
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	type window struct {
+		lo, hi, bulk int
+	}
+
+	out := make([]BatchResult, len(targets))
+	windows := make([]window, len(targets))
+	d := pr.objective.dominant(pr.reduced, pr.weights)
+	cells := pr.opts.tableCells()
+	limit := 0
+	for i, target := range targets {
+		if target <= 0 {
+			out[i].Result = &Result{Packages: []int{}, Packs: []PackQuantity{}}
+			continue
+		}
+
+		lo, hi, err := pr.window(target)
+		if err != nil {
+			out[i].Err = err
+			continue
+		}
+
+		bulk := setAside(pr.reduced, d, lo)
+		shift := bulk * pr.reduced[d]
+		if hi-shift >= cells {
+			out[i].Err = fmt.Errorf("%w: %d totals", ErrTableTooLarge, hi-shift)
+			continue
+		}
+
+		windows[i] = window{lo: lo - shift, hi: hi - shift, bulk: bulk}
+		limit = max(limit, hi-shift)
+	}
+
+	t, err := p.cache.table(ctx, pr.reduced, pr.weights, pr.objective, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, target := range targets {
+		if out[i].Result != nil || out[i].Err != nil {
+			continue
+		}
+
+		w := windows[i]
+		sol, err := t.solution(w.lo, w.hi, w.bulk, d, pr.below)
+		if err == nil {
+			out[i].Result, err = pr.result(ctx, sol, target)
+		}
+
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+
+		out[i].Err = err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,86 @@
+package packing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolveBatch(t *testing.T) {
+	exact, err := LookupStrategy("exact-or-fail")
+	assert.NoError(t, err)
+
+	under, err := LookupStrategy("under-fulfil")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		packs    []int
+		orders   []int
+		opts     Options
+		expected []BatchResult
+	}{
+		{packs: packs, orders: []int{1, 501, 0, 12001}, expected: []BatchResult{
+			{Result: &Result{Packages: []int{250}, Packs: []PackQuantity{{Size: 250, Quantity: 1}}}},
+			{Result: &Result{Packages: []int{500, 250}, Packs: []PackQuantity{
+				{Size: 500, Quantity: 1}, {Size: 250, Quantity: 1},
+			}}},
+			{Result: &Result{Packages: []int{}, Packs: []PackQuantity{}}},
+			{Result: &Result{Packages: []int{5000, 5000, 2000, 250}, Packs: []PackQuantity{
+				{Size: 5000, Quantity: 2}, {Size: 2000, Quantity: 1}, {Size: 250, Quantity: 1},
+			}}},
+		}},
+		{packs: packs, orders: []int{750, 751}, opts: Options{Strategy: exact}, expected: []BatchResult{
+			{Result: &Result{Packages: []int{500, 250}, Packs: []PackQuantity{
+				{Size: 500, Quantity: 1}, {Size: 250, Quantity: 1},
+			}}},
+			{Err: ErrNoSolution},
+		}},
+		{packs: []int{3, 4, 5}, orders: []int{10, 2}, opts: Options{Stock: map[int]int{5: 1}}, expected: []BatchResult{
+			{Result: &Result{Packages: []int{4, 3, 3}, Packs: []PackQuantity{{Size: 4, Quantity: 1}, {Size: 3, Quantity: 2}}}},
+			{Result: &Result{Packages: []int{3}, Packs: []PackQuantity{{Size: 3, Quantity: 1}}}},
+		}},
+		{packs: packs, orders: []int{}, expected: []BatchResult{}},
+		{packs: []int{3, 4}, orders: []int{10}, opts: Options{Strategy: under, Stock: map[int]int{3: 0, 4: 0}}, expected: []BatchResult{
+			{Result: &Result{Packages: []int{}, Packs: []PackQuantity{}}},
+		}},
+	}
+
+	repo := New()
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			out, err := repo.SolveBatch(context.Background(), test.packs, test.orders, test.opts)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, out)
+		})
+	}
+}
+
+func TestSolveBatchMatchesSolve(t *testing.T) {
+	orders := []int{50_000_001}
+	for order := 1; order <= 3000; order += 7 {
+		orders = append(orders, order)
+	}
+
+	repo := New()
+	out, err := repo.SolveBatch(context.Background(), packsNonDivisible, orders, Options{Alternatives: 3, Explain: true})
+	assert.NoError(t, err)
+
+	for i, order := range orders {
+		expected, err := repo.Solve(context.Background(), packsNonDivisible, order, Options{Alternatives: 3, Explain: true})
+
+		assert.NoError(t, err)
+		assert.NoError(t, out[i].Err, "order %d", order)
+		assert.Equal(t, expected, out[i].Result, "order %d", order)
+	}
+}
+
+func TestSolveBatchCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := New().SolveBatch(ctx, packsNonDivisible, []int{50_000_001}, Options{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,192 @@
+package packing
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Cache memoises the tables behind Solve and the analyses of pack sets, so requests against the same pack set don't
+// tabulate the same totals over and over. Tables are kept per pack set and ranking, least recently used entries are
+// evicted once the cache holds more than its size in bytes.
+//
+// The pack set itself is part of the key, so a table can never answer for a different set. Purge is still called when
+// the sizes change, so the tables of a set that is gone don't take up memory until they are evicted.
+type Cache struct {
+	lock     sync.Mutex
+	maxBytes int
+	bytes    int
+	entries  *list.List // of *cacheEntry, most recently used first
+	index    map[string]*list.Element
+	hits     int
+	misses   int
+}
+
+// cacheEntry holds either a table or an analysis.
+type cacheEntry struct {
+	key      string
+	table    *table
+	analysis *Analysis
+}
+
+func (e *cacheEntry) size() int {
+	if e.table != nil {
+		return e.table.size()
+	}
+
+	return e.analysis.size()
+}
+
+// CacheStats is a snapshot of the cache. A lookup is a hit if a cached table already went far enough for the order.
+type CacheStats struct {
+	Hits    int
+	Misses  int
+	Entries int
+	Bytes   int
+}
+
+// NewCache returns a cache that holds on to at most maxBytes of tables.
+func NewCache(maxBytes int) *Cache {
+	return &Cache{
+		maxBytes: maxBytes,
+		entries:  list.New(),
+		index:    map[string]*list.Element{},
+	}
+}
+
+// Stats returns the hit and miss counts since the cache was created, and what it currently holds.
+func (c *Cache) Stats() CacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return CacheStats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Entries: c.entries.Len(),
+		Bytes:   c.bytes,
+	}
+}
+
+// Purge drops every cached table.
+func (c *Cache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries.Init()
+	c.index = map[string]*list.Element{}
+	c.bytes = 0
+}
+
+// table returns a table of packs that goes up to at least limit, see newTable. A cached table that falls short is
+// extended rather than tabulated again. A nil cache tabulates a new table every time.
+func (c *Cache) table(
+	ctx context.Context, packs []int, weights []score, objective Objective, limit int,
+) (*table, error) {
+	if c == nil {
+		return newTable(ctx, packs, weights, objective, limit)
+	}
+
+	key := fmt.Sprint(packs, weights, objective)
+
+	c.lock.Lock()
+	var cached *table
+	if e, ok := c.index[key]; ok {
+		c.entries.MoveToFront(e)
+		cached = e.Value.(*cacheEntry).table
+	}
+
+	if cached != nil && limit < len(cached.scores) {
+		c.hits++
+		c.lock.Unlock()
+
+		return cached, nil
+	}
+	c.misses++
+	c.lock.Unlock()
+
+	// tabulating happens outside the lock, so a slow table doesn't hold up requests for other pack sets. Two requests
+	// missing on the same key at once both tabulate, store keeps the larger table.
+	var t *table
+	var err error
+	if cached != nil {
+		t, err = cached.extend(ctx, limit)
+	} else {
+		t, err = newTable(ctx, packs, weights, objective, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(key, t)
+
+	return t, nil
+}
+
+// analysis returns the analysis of packs, see analyse. Analyses are cached next to the tables they are read from. A
+// cached analysis is returned whatever cells is, it takes no table of its own anymore.
+func (c *Cache) analysis(ctx context.Context, packs []int, cells int) (*Analysis, error) {
+	if c == nil {
+		return analyse(ctx, nil, packs, cells)
+	}
+
+	key := fmt.Sprint("analysis", packs)
+
+	c.lock.Lock()
+	if e, ok := c.index[key]; ok {
+		c.entries.MoveToFront(e)
+		c.hits++
+		c.lock.Unlock()
+
+		return e.Value.(*cacheEntry).analysis, nil
+	}
+	c.misses++
+	c.lock.Unlock()
+
+	a, err := analyse(ctx, c, packs, cells)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.index[key]; !ok {
+		c.index[key] = c.entries.PushFront(&cacheEntry{key: key, analysis: a})
+		c.bytes += a.size()
+		c.evict()
+	}
+
+	return a, nil
+}
+
+// store caches t under key, unless a table at least as large is cached already.
+func (c *Cache) store(key string, t *table) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.index[key]; ok {
+		entry := e.Value.(*cacheEntry)
+		if len(entry.table.scores) >= len(t.scores) {
+			return
+		}
+
+		c.bytes += t.size() - entry.table.size()
+		entry.table = t
+		c.entries.MoveToFront(e)
+	} else {
+		c.index[key] = c.entries.PushFront(&cacheEntry{key: key, table: t})
+		c.bytes += t.size()
+	}
+
+	c.evict()
+}
+
+// evict drops the least recently used entries until the cache fits its size again. The lock must be held.
+func (c *Cache) evict() {
+	for c.bytes > c.maxBytes && c.entries.Len() > 0 {
+		entry := c.entries.Remove(c.entries.Back()).(*cacheEntry)
+		delete(c.index, entry.key)
+		c.bytes -= entry.size()
+	}
+}
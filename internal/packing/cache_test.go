@@ -0,0 +1,60 @@
+package packing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	cache := NewCache(1 << 20)
+	repo := NewCached(cache)
+
+	// the first order tabulates, a smaller order is answered by the same table, a larger one extends it.
+	orders := []int{1000, 10, 1000, 4000}
+	expected := []CacheStats{
+		{Hits: 0, Misses: 1, Entries: 1},
+		{Hits: 1, Misses: 1, Entries: 1},
+		{Hits: 2, Misses: 1, Entries: 1},
+		{Hits: 2, Misses: 2, Entries: 1},
+	}
+
+	for i, order := range orders {
+		out, err := repo.Solve(context.Background(), packsNonDivisible, order, Options{})
+		assert.NoError(t, err)
+		assert.Equal(t, New().Calculate(packsNonDivisible, order), out.Packages)
+
+		stats := cache.Stats()
+		stats.Bytes = 0
+		assert.Equal(t, expected[i], stats, "order %d", order)
+	}
+
+	cache.Purge()
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 2}, cache.Stats())
+}
+
+func TestCacheEvicts(t *testing.T) {
+	cache := NewCache(1 << 15)
+	repo := NewCached(cache)
+
+	for _, set := range [][]int{{23, 31, 53}, {17, 29, 61}, {11, 34, 59, 70}} {
+		_, err := repo.Solve(context.Background(), set, 1000, Options{})
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, cache.Stats().Bytes, 1<<15)
+	}
+
+	assert.Less(t, cache.Stats().Entries, 3)
+}
+
+func TestCacheMatchesSolve(t *testing.T) {
+	repo := NewCached(NewCache(1 << 20))
+	for order := 5000; order >= 1; order -= 3 {
+		out, err := repo.Solve(context.Background(), packsNonDivisible, order, Options{Alternatives: 3})
+		assert.NoError(t, err)
+
+		expected, err := New().Solve(context.Background(), packsNonDivisible, order, Options{Alternatives: 3})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, out, "order %d", order)
+	}
+}
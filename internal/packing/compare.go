@@ -0,0 +1,110 @@
+package packing
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+)
+
+// listedChanges is the number of changed orders a comparison lists, the rest are only counted.
+const listedChanges = 100
+
+// Comparison is what replacing the current pack sizes with proposed ones does to a range of orders under rules 1 to 3.
+type Comparison struct {
+	// Analysis is the analysis of the proposed sizes, Period and Bound are 0 if the sizes are too large to analyse.
+	Analysis *Analysis
+	// MaxOverage is the most items sent above an order in the range, MaxOverageOrder the first order that gets it.
+	MaxOverage      int
+	MaxOverageOrder int
+	AverageOverage  float64
+	// Changed is the number of orders whose packages differ from the current sizes, Changes lists the first of them.
+	Changed int
+	Changes []Change
+}
+
+// Change is an order that is packed differently by the proposed sizes.
+type Change struct {
+	Order    int
+	Current  []int
+	Proposed []int
+}
+
+// Compare packs every order from from to to, inclusive, with both proposed and current and reports the overage of
+// proposed together with the orders that change. Both sets are solved as a batch, so each only tabulates once. No
+// table takes more than maxTableBytes, see Options.MaxTableBytes. The orders are compared by their number of packs per
+// size, only the listed changes list every pack on their own, and those return ErrTooManyPacks if they hold more than
+// maxPacks packs, unless maxPacks is 0.
+func (p *Packager) Compare(
+	ctx context.Context, proposed, current []int, from, to, maxTableBytes, maxPacks int,
+) (*Comparison, error) {
+	proposed = slices.Clone(proposed)
+	sort.Ints(proposed)
+
+	opts := Options{MaxTableBytes: maxTableBytes, OmitPackages: true}
+	analysis, err := p.cache.analysis(ctx, proposed, opts.tableCells())
+	if errors.Is(err, ErrAnalysisTooLarge) {
+		// the periodicity needs a table above the reduction bound, the gaps of the set don't.
+		analysis, err = analyseGaps(ctx, proposed, opts.tableCells())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// counting up to to itself would overflow if to is math.MaxInt.
+	orders := make([]int, 0, to-from+1)
+	for i := 0; i <= to-from; i++ {
+		orders = append(orders, from+i)
+	}
+
+	proposedResults, err := p.SolveBatch(ctx, proposed, orders, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	currentResults, err := p.SolveBatch(ctx, current, orders, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Comparison{Analysis: analysis}
+	total := 0
+	for i, order := range orders {
+		// rules 1 to 3 always have a solution without stock, only orders too large to pack fail.
+		for _, res := range []BatchResult{proposedResults[i], currentResults[i]} {
+			if res.Err != nil {
+				return nil, res.Err
+			}
+		}
+
+		packs := proposedResults[i].Result.Packs
+		overage := itemsOf(packs) - order
+		total += overage
+		if overage > out.MaxOverage || out.MaxOverageOrder == 0 {
+			out.MaxOverage, out.MaxOverageOrder = overage, order
+		}
+
+		if slices.Equal(packs, currentResults[i].Result.Packs) {
+			continue
+		}
+
+		out.Changed++
+		if len(out.Changes) < listedChanges {
+			change := Change{Order: order}
+			if change.Current, err = Ungroup(currentResults[i].Result.Packs, maxPacks); err != nil {
+				return nil, err
+			}
+			if change.Proposed, err = Ungroup(packs, maxPacks); err != nil {
+				return nil, err
+			}
+
+			out.Changes = append(out.Changes, change)
+		}
+	}
+
+	if len(orders) > 0 {
+		out.AverageOverage = float64(total) / float64(len(orders))
+	}
+
+	return out, nil
+}
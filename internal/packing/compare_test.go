@@ -0,0 +1,88 @@
+package packing
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		proposed []int
+		current  []int
+		from     int
+		to       int
+	}{
+		{proposed: packs, current: packs, from: 1, to: 12001},
+		{proposed: []int{250, 500, 1000}, current: packs, from: 1, to: 12001},
+		{proposed: packsNonDivisible, current: packs, from: 500, to: 3000},
+		{proposed: []int{6, 9, 20}, current: []int{3, 4, 5}, from: 1, to: 200},
+		// too large to analyse, the comparison goes on without the periodicity.
+		{proposed: []int{250, 500, 1000, 2000, 4999}, current: packs, from: 1, to: 1000},
+		{proposed: []int{997, 1009, 5000}, current: packs, from: 1, to: 1000},
+	}
+
+	repo := New()
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			out, err := repo.Compare(context.Background(), test.proposed, test.current, test.from, test.to, 0, 0)
+			assert.NoError(t, err)
+
+			// the same figures, order by order.
+			maxOverage, maxOrder, total, changed := 0, 0, 0, 0
+			for order := test.from; order <= test.to; order++ {
+				proposed := repo.Calculate(test.proposed, order)
+				if overage := sumSlice(proposed) - order; maxOrder == 0 || overage > maxOverage {
+					maxOverage, maxOrder = overage, order
+				}
+				total += sumSlice(proposed) - order
+
+				if !slices.Equal(proposed, repo.Calculate(test.current, order)) {
+					changed++
+				}
+			}
+
+			assert.Equal(t, maxOverage, out.MaxOverage)
+			assert.Equal(t, maxOrder, out.MaxOverageOrder)
+			assert.InDelta(t, float64(total)/float64(test.to-test.from+1), out.AverageOverage, 1e-9)
+			assert.Equal(t, changed, out.Changed)
+			assert.Len(t, out.Changes, min(changed, listedChanges))
+
+			for _, change := range out.Changes {
+				assert.Equal(t, repo.Calculate(test.proposed, change.Order), change.Proposed)
+				assert.Equal(t, repo.Calculate(test.current, change.Order), change.Current)
+			}
+		})
+	}
+}
+
+func TestCompareTooManyPacks(t *testing.T) {
+	tests := []struct {
+		proposed   []int
+		current    []int
+		order      int
+		maxOverage int
+		err        error
+	}{
+		// no change is listed, so the packs are only counted.
+		{proposed: []int{250}, current: []int{250}, order: 1_000_000_001, maxOverage: 249},
+		{proposed: []int{250}, current: []int{500}, order: 1_000_000_000, err: ErrTooManyPacks},
+	}
+
+	repo := New()
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			out, err := repo.Compare(context.Background(), test.proposed, test.current, test.order, test.order, 0, 1000)
+			assert.ErrorIs(t, err, test.err)
+			if test.err != nil {
+				return
+			}
+
+			assert.Equal(t, test.maxOverage, out.MaxOverage)
+			assert.Equal(t, 0, out.Changed)
+		})
+	}
+}
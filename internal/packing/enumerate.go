@@ -0,0 +1,68 @@
+package packing
+
+import (
+	"context"
+	"slices"
+)
+
+// AllOptimal returns up to limit distributions of packs for target that are equally good under rules 1 to 3, i.e. that
+// send the same number of items in the same number of packs. The first one is always the distribution Calculate
+// returns, the others follow in descending order of their largest packs. Each distribution is sorted by reverse size.
+func (p *Packager) AllOptimal(ctx context.Context, packs []int, target, limit int) ([][]int, error) {
+	if limit <= 0 {
+		return [][]int{}, nil
+	}
+
+	res, err := p.Solve(ctx, packs, target, Options{Alternatives: limit - 1})
+	if err != nil {
+		return nil, err
+	}
+
+	return append([][]int{res.Packages}, res.Alternatives...), nil
+}
+
+// enumerate returns up to limit count vectors of equally good distributions, starting with the one counts returns.
+// Under the default objective every best distribution of a total above the reduction bound contains the largest pack
+// (smaller packs can always be swapped for strictly fewer largest ones there), so the bulk is shared by all of them and
+// only the remainder needs to be enumerated. With costs, a pack that is exactly as cost effective as the dominant one
+// can make some alternatives of very large orders fall outside the remainder, those are not returned.
+func (s *solution) enumerate(limit int) [][]int {
+	primary := s.counts()
+	out := [][]int{primary}
+
+	current := make([]int, len(s.table.packs))
+	var walk func(total, maxIndex int)
+	walk = func(total, maxIndex int) {
+		if len(out) >= limit {
+			return
+		}
+
+		if total == 0 {
+			counts := slices.Clone(current)
+			counts[s.dominant] += s.bulk
+			if !slices.Equal(counts, primary) {
+				out = append(out, counts)
+			}
+
+			return
+		}
+
+		// packs are only ever taken in descending order, so every multiset is produced exactly once. Removing a pack
+		// from a best distribution leaves a best distribution of the rest, so we only follow totals whose score is
+		// exactly one pack less.
+		for j := maxIndex; j >= 0; j-- {
+			pack := s.table.packs[j]
+			if pack > total || !s.table.reachable(total-pack) ||
+				s.table.scores[total-pack].add(s.table.weights[j]) != s.table.scores[total] {
+				continue
+			}
+
+			current[j]++
+			walk(total-pack, j)
+			current[j]--
+		}
+	}
+	walk(s.total, len(s.table.packs)-1)
+
+	return out
+}
@@ -0,0 +1,58 @@
+package packing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllOptimal(t *testing.T) {
+	tests := []struct {
+		packs    []int
+		order    int
+		limit    int
+		expected [][]int
+	}{
+		{packs: packs, order: 749, limit: 10, expected: [][]int{{500, 250}}},
+		{packs: packsNonDivisible, order: 1000, limit: 10, expected: [][]int{
+			{70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 34, 34, 11, 11},
+			{70, 70, 59, 59, 59, 59, 59, 59, 59, 59, 59, 59, 59, 59, 59, 59, 34},
+		}},
+		{packs: packsNonDivisible, order: 1000, limit: 1, expected: [][]int{
+			{70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 70, 34, 34, 11, 11},
+		}},
+		{packs: []int{1, 2, 3}, order: 4, limit: 10, expected: [][]int{{3, 1}, {2, 2}}},
+		{packs: packs, order: 0, limit: 10, expected: [][]int{{}}},
+		{packs: packs, order: 1, limit: 10, expected: [][]int{{250}}},
+		{packs: packs, order: 1, limit: 0, expected: [][]int{}},
+	}
+
+	repo := New()
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			out, err := repo.AllOptimal(context.Background(), test.packs, test.order, test.limit)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, out)
+		})
+	}
+}
+
+func TestAllOptimalMatchesCalculate(t *testing.T) {
+	repo := New()
+	for order := 1; order <= 5000; order++ {
+		expected := repo.Calculate(packsNonDivisible, order)
+		out, err := repo.AllOptimal(context.Background(), packsNonDivisible, order, 50)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, out[0], "order %d", order)
+
+		// every alternative must be exactly as good as the primary distribution.
+		for _, alternative := range out[1:] {
+			assert.Equal(t, sumSlice(expected), sumSlice(alternative), "order %d", order)
+			assert.Equal(t, len(expected), len(alternative), "order %d", order)
+		}
+	}
+}
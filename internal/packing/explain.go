@@ -0,0 +1,114 @@
+package packing
+
+import "sort"
+
+// runnerUps is the number of rejected candidates an explanation lists.
+const runnerUps = 5
+
+// Explanation tells why a distribution was chosen. Item counts are in the units of the order, Overage is negative if
+// less than the order is sent.
+type Explanation struct {
+	// Objective is the ranking that was applied, tiebreakers included.
+	Objective Objective
+	Items     int
+	Overage   int
+	PackCount int
+	// Cost is only filled in if the objective ranks by cost.
+	Cost int
+	// Rejected are the next best candidates the strategy accepted, best first. Every candidate is the best distribution
+	// of a different total.
+	Rejected []Rejection
+}
+
+// Rejection is a candidate that lost against the chosen distribution.
+type Rejection struct {
+	Packages  []int
+	Items     int
+	Overage   int
+	PackCount int
+	Cost      int
+	// DecidedBy is the first criterion of the objective on which the chosen distribution ranks ahead.
+	DecidedBy Criterion
+}
+
+// Rule returns the number of the README rule the criterion stands for, or 0 if it doesn't stand for one.
+func (c Criterion) Rule() int {
+	switch c {
+	case CriterionItems:
+		return 2
+	case CriterionPacks:
+		return 3
+	}
+
+	return 0
+}
+
+// decisive returns the first criterion on which a and b differ, or an empty criterion if they are tied.
+func (o Objective) decisive(aTotal int, a score, bTotal int, b score) Criterion {
+	for _, c := range o {
+		if (Objective{c}).compare(aTotal, a, bTotal, b) != 0 {
+			return c
+		}
+	}
+
+	return ""
+}
+
+// explain builds the explanation of sol. packs are the original sizes, divisor the common divisor they were reduced by
+// and target the original order.
+func (s *solution) explain(packs []int, divisor, target int) *Explanation {
+	t := s.table
+	shift := s.bulk * t.packs[s.dominant]
+
+	// what a total of the remainder adds up to once the bulk is put back.
+	totalScore := func(total int) score {
+		return t.scores[total].add(t.weights[s.dominant].scale(s.bulk))
+	}
+
+	chosen := totalScore(s.total)
+	out := &Explanation{
+		Objective: t.objective,
+		Items:     (s.total + shift) * divisor,
+		Overage:   (s.total+shift)*divisor - target,
+		PackCount: chosen.packs,
+	}
+	if t.objective.uses(CriterionCost) {
+		out.Cost = chosen.cost
+	}
+
+	var candidates []int
+	for i := s.lo; i <= s.hi; i++ {
+		if i != s.total && t.reachable(i) {
+			candidates = append(candidates, i)
+		}
+	}
+
+	// shifting every total by the bulk doesn't change how they rank, so the remainder scores are enough to sort them.
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return t.compare(candidates[a], candidates[b], s.below) < 0
+	})
+
+	// below the order the larger totals are the closer ones, so their sign is flipped for ranking.
+	sign := 1
+	if s.below {
+		sign = -1
+	}
+
+	for _, total := range candidates[:min(runnerUps, len(candidates))] {
+		sc := totalScore(total)
+		rejection := Rejection{
+			Packages:  remap(s.countsOf(total), packs),
+			Items:     (total + shift) * divisor,
+			Overage:   (total+shift)*divisor - target,
+			PackCount: sc.packs,
+			DecidedBy: t.objective.decisive(sign*s.total, t.scores[s.total], sign*total, t.scores[total]),
+		}
+		if t.objective.uses(CriterionCost) {
+			rejection.Cost = sc.cost
+		}
+
+		out.Rejected = append(out.Rejected, rejection)
+	}
+
+	return out
+}
@@ -0,0 +1,101 @@
+package packing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	repo := New()
+
+	res, err := repo.Solve(context.Background(), packs, 749, Options{Explain: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{500, 250}, res.Packages)
+	assert.Equal(t, Objective{CriterionItems, CriterionPacks}, res.Explanation.Objective)
+	assert.Equal(t, 750, res.Explanation.Items)
+	assert.Equal(t, 1, res.Explanation.Overage)
+	assert.Equal(t, 2, res.Explanation.PackCount)
+	assert.Len(t, res.Explanation.Rejected, runnerUps)
+	assert.Equal(t, Rejection{
+		Packages:  []int{1000},
+		Items:     1000,
+		Overage:   251,
+		PackCount: 1,
+		DecidedBy: CriterionItems,
+	}, res.Explanation.Rejected[0])
+	assert.Equal(t, 2, res.Explanation.Rejected[0].DecidedBy.Rule())
+}
+
+func TestExplainStrategy(t *testing.T) {
+	s, err := LookupStrategy("min-packs-then-min-items")
+	assert.NoError(t, err)
+
+	res, err := New().Solve(context.Background(), packs, 749, Options{Strategy: s, Explain: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1000}, res.Packages)
+
+	// single packs rank ahead of everything else, so only the items can decide between them.
+	assert.Equal(t, []int{2000}, res.Explanation.Rejected[0].Packages)
+	assert.Equal(t, CriterionItems, res.Explanation.Rejected[0].DecidedBy)
+	assert.Equal(t, []int{5000}, res.Explanation.Rejected[1].Packages)
+	assert.Equal(t, []int{500, 250}, res.Explanation.Rejected[2].Packages)
+	assert.Equal(t, CriterionPacks, res.Explanation.Rejected[2].DecidedBy)
+	assert.Equal(t, 3, res.Explanation.Rejected[2].DecidedBy.Rule())
+}
+
+func TestExplainLargeOrder(t *testing.T) {
+	res, err := New().Solve(context.Background(), packs, 12_000_001, Options{Explain: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 12_000_250, res.Explanation.Items)
+	assert.Equal(t, 249, res.Explanation.Overage)
+	assert.Equal(t, 2401, res.Explanation.PackCount)
+
+	// the bulk is put back into the rejected candidates too.
+	rejected := res.Explanation.Rejected[0]
+	assert.Equal(t, 12_000_500, rejected.Items)
+	assert.Equal(t, 12_000_500, sumSlice(rejected.Packages))
+	assert.Equal(t, len(rejected.Packages), rejected.PackCount)
+	assert.Equal(t, CriterionItems, rejected.DecidedBy)
+}
+
+func TestExplainStock(t *testing.T) {
+	res, err := New().Solve(context.Background(), packs, 12001, Options{Stock: map[int]int{5000: 1}, Explain: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{5000, 2000, 2000, 2000, 1000, 250}, res.Packages)
+	assert.Equal(t, 12250, res.Explanation.Items)
+	assert.Equal(t, 6, res.Explanation.PackCount)
+
+	for _, rejected := range res.Explanation.Rejected {
+		assert.Equal(t, rejected.Items, sumSlice(rejected.Packages))
+		assert.LessOrEqual(t, countOf(rejected.Packages, 5000), 1)
+	}
+}
+
+// countOf returns how many times pack appears in a distribution.
+func countOf(distribution []int, pack int) int {
+	out := 0
+	for _, item := range distribution {
+		if item == pack {
+			out++
+		}
+	}
+
+	return out
+}
+
+func TestExplainUnderFulfil(t *testing.T) {
+	s, err := LookupStrategy("under-fulfil")
+	assert.NoError(t, err)
+
+	res, err := New().Solve(context.Background(), packs, 999, Options{Strategy: s, Explain: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{500, 250}, res.Packages)
+	assert.Equal(t, -249, res.Explanation.Overage)
+
+	// below the order, the larger totals are the closer ones.
+	assert.Equal(t, []int{500}, res.Explanation.Rejected[0].Packages)
+	assert.Equal(t, -499, res.Explanation.Rejected[0].Overage)
+	assert.Equal(t, CriterionItems, res.Explanation.Rejected[0].DecidedBy)
+}
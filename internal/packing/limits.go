@@ -0,0 +1,78 @@
+package packing
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+var (
+	ErrOrderTooLarge = fmt.Errorf("order is too large to pack")
+	ErrTableTooLarge = fmt.Errorf("pack sizes and stock need too large a table")
+	ErrTooManyPacks  = fmt.Errorf("distribution holds more packs than allowed")
+)
+
+// cellBytes is what a single total of a table takes, its score and the pack it was reached with.
+const cellBytes = int(unsafe.Sizeof(score{}) + unsafe.Sizeof(0))
+
+// defaultTableCells caps the totals of a table if the options don't, so a table never takes more than a few
+// gigabytes. Without stock the table stays below the reduction bound plus a couple of largest packs, so only huge,
+// coprime pack sizes get there. Within stock it grows with the order, see solveBounded.
+const defaultTableCells = 1 << 27
+
+// tableCells returns the most totals a table of the calculation may have, see Options.MaxTableBytes.
+func (o Options) tableCells() int {
+	if o.MaxTableBytes <= 0 {
+		return defaultTableCells
+	}
+
+	return max(1, o.MaxTableBytes/cellBytes)
+}
+
+// check makes sure nothing about target overflows an int: the totals of its window, which may go up to a largest pack
+// past it, and the cost of any distribution in there, which holds at most a smallest pack per item.
+func (pr *problem) check(target int) error {
+	largest := pr.packs[len(pr.packs)-1]
+	if target > math.MaxInt-largest {
+		return fmt.Errorf("%w: %d", ErrOrderTooLarge, target)
+	}
+
+	if !pr.objective.uses(CriterionCost) {
+		return nil
+	}
+
+	packs := (target + largest) / pr.packs[0]
+	for _, w := range pr.weights {
+		if w.cost > 0 && packs > math.MaxInt/w.cost {
+			return fmt.Errorf("%w: %d costs more than can be counted", ErrOrderTooLarge, target)
+		}
+	}
+
+	return nil
+}
+
+// newResult maps counts onto the sizes, unless they hold more packs than the options allow. Packages holds every pack
+// on its own, so this is what keeps a huge order from taking all the memory there is. Packs doesn't grow with the
+// order, so it is never capped.
+func (pr *problem) newResult(counts []int) (*Result, error) {
+	res := &Result{Packs: group(counts, pr.packs)}
+	if !pr.lists() {
+		return res, nil
+	}
+
+	if n := pr.opts.MaxPacks; n > 0 && sumSlice(counts) > n {
+		return nil, fmt.Errorf("%w: %d packs, at most %d", ErrTooManyPacks, sumSlice(counts), n)
+	}
+
+	if !pr.opts.OmitPackages {
+		res.Packages = remap(counts, pr.packs)
+	}
+
+	return res, nil
+}
+
+// lists reports whether the result lists every pack on its own somewhere. Alternatives and explanations do so even if
+// packages are omitted.
+func (pr *problem) lists() bool {
+	return !pr.opts.OmitPackages || pr.opts.Alternatives > 0 || pr.opts.Explain
+}
@@ -0,0 +1,74 @@
+package packing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolveLimits(t *testing.T) {
+	tests := []struct {
+		order    int
+		opts     Options
+		expected []int
+		grouped  []PackQuantity
+		err      error
+	}{
+		{order: 12_001, opts: Options{MaxPacks: 4}, expected: []int{5000, 5000, 2000, 250}},
+		{order: 12_001, opts: Options{MaxPacks: 3}, err: ErrTooManyPacks},
+		{order: math.MaxInt - 5000, opts: Options{MaxPacks: 1_000_000}, err: ErrTooManyPacks},
+		// without packages, only alternatives list every pack.
+		{order: 10_000_000_001, opts: Options{MaxPacks: 1_000_000, OmitPackages: true},
+			grouped: []PackQuantity{{Size: 5000, Quantity: 2_000_000}, {Size: 250, Quantity: 1}}},
+		{order: 10_000_000_001, opts: Options{MaxPacks: 1_000_000, OmitPackages: true, Alternatives: 1}, err: ErrTooManyPacks},
+		// rounding up to the next pack would overflow.
+		{order: math.MaxInt - 4999, err: ErrOrderTooLarge},
+		{order: math.MaxInt, err: ErrOrderTooLarge},
+		{order: 1 << 50, opts: Options{
+			Objective: Objective{CriterionCost},
+			Costs:     map[int]int{250: 1 << 30, 500: 1, 1000: 1, 2000: 1, 5000: 1},
+		}, err: ErrOrderTooLarge},
+		// within stock the table grows with the order.
+		{order: 1 << 40, opts: Options{Stock: map[int]int{5000: 1}}, err: ErrTableTooLarge},
+		{order: 20_000, opts: Options{Stock: map[int]int{5000: 10}}, expected: []int{5000, 5000, 5000, 5000}},
+		{order: 20_000, opts: Options{Stock: map[int]int{5000: 10}, MaxTableBytes: 1 << 10}, err: ErrTableTooLarge},
+	}
+
+	for _, repo := range []*Packager{New(), NewCached(NewCache(1 << 20))} {
+		for i, test := range tests {
+			t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+				res, err := repo.Solve(context.Background(), packs, test.order, test.opts)
+				if test.err != nil {
+					assert.ErrorIs(t, err, test.err)
+					return
+				}
+
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, res.Packages)
+				if test.grouped != nil {
+					assert.Equal(t, test.grouped, res.Packs)
+				}
+			})
+		}
+	}
+}
+
+func TestSolveBatchLimits(t *testing.T) {
+	out, err := NewCached(NewCache(1<<20)).SolveBatch(context.Background(), packs, []int{251, math.MaxInt}, Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{500}, out[0].Result.Packages)
+	assert.ErrorIs(t, out[1].Err, ErrOrderTooLarge)
+}
+
+func TestSolveBatchTableLimit(t *testing.T) {
+	coprime := []int{9973, 10000}
+	opts := Options{MaxTableBytes: 1 << 20}
+
+	out, err := NewCached(NewCache(1<<20)).SolveBatch(context.Background(), coprime, []int{10000, 99_000_000}, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{10000}, out[0].Result.Packages)
+	assert.ErrorIs(t, out[1].Err, ErrTableTooLarge)
+}
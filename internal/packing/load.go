@@ -0,0 +1,90 @@
+package packing
+
+import (
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrContainerInvalid = fmt.Errorf("container needs a name and a positive capacity")
+	ErrPackTooLarge     = fmt.Errorf("pack does not fit into the innermost container")
+)
+
+// Container is a level of the load plan. The innermost container holds packs and its capacity is in items, every
+// other container holds containers of the level below and its capacity is their number.
+type Container struct {
+	Name     string
+	Capacity int
+}
+
+// Load is a single container of a load plan. Packages is only set for the innermost containers, Contents for the rest.
+type Load struct {
+	Container string
+	Items     int
+	Packages  []int
+	Contents  []Load
+}
+
+// PlanLoad loads packages into the levels of containers, innermost first, and returns the outermost containers. Every
+// pack must fit into the innermost container.
+//
+// Packs are loaded first fit decreasing: the largest packs go first, each into the first container that still has room
+// for it. That never takes more than 11/9 of the fewest containers possible plus one, and usually just as many, without
+// having to search every loading. Containers of the levels above hold a fixed number of containers, so they are simply
+// filled up in order.
+func PlanLoad(packages []int, levels []Container) ([]Load, error) {
+	if len(levels) == 0 {
+		return nil, nil
+	}
+
+	for _, level := range levels {
+		if level.Name == "" || level.Capacity <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrContainerInvalid, level.Name)
+		}
+	}
+
+	packs := append([]int(nil), packages...)
+	sort.Sort(sort.Reverse(sort.IntSlice(packs)))
+
+	inner := levels[0]
+	var loads []Load
+	// containers before open have no room left for even the smallest pack, so they are never looked at again.
+	open := 0
+	for _, pack := range packs {
+		if pack > inner.Capacity {
+			return nil, fmt.Errorf("%w: %d items into %s of %d", ErrPackTooLarge, pack, inner.Name, inner.Capacity)
+		}
+
+		for open < len(loads) && loads[open].Items+packs[len(packs)-1] > inner.Capacity {
+			open++
+		}
+
+		i := open
+		for i < len(loads) && loads[i].Items+pack > inner.Capacity {
+			i++
+		}
+
+		if i == len(loads) {
+			loads = append(loads, Load{Container: inner.Name})
+		}
+
+		loads[i].Items += pack
+		loads[i].Packages = append(loads[i].Packages, pack)
+	}
+
+	for _, level := range levels[1:] {
+		outer := make([]Load, 0, (len(loads)+level.Capacity-1)/level.Capacity)
+		for start := 0; start < len(loads); start += level.Capacity {
+			load := Load{Container: level.Name, Contents: loads[start:min(start+level.Capacity, len(loads))]}
+			for _, content := range load.Contents {
+				load.Items += content.Items
+			}
+
+			outer = append(outer, load)
+		}
+
+		loads = outer
+	}
+
+	return loads, nil
+}
@@ -0,0 +1,75 @@
+package packing
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanLoad(t *testing.T) {
+	carton := Container{Name: "carton", Capacity: 5000}
+	pallet := Container{Name: "pallet", Capacity: 2}
+
+	tests := []struct {
+		packages []int
+		levels   []Container
+		expected []Load
+		err      error
+	}{
+		{packages: []int{500, 250}, levels: nil, expected: nil},
+		{packages: []int{500, 250}, levels: []Container{carton}, expected: []Load{
+			{Container: "carton", Items: 750, Packages: []int{500, 250}},
+		}},
+		// the 1000 goes back into the first carton, next to the 2000s.
+		{packages: []int{2000, 2000, 1000, 5000, 250}, levels: []Container{carton}, expected: []Load{
+			{Container: "carton", Items: 5000, Packages: []int{5000}},
+			{Container: "carton", Items: 5000, Packages: []int{2000, 2000, 1000}},
+			{Container: "carton", Items: 250, Packages: []int{250}},
+		}},
+		{packages: []int{5000, 5000, 5000, 250}, levels: []Container{carton, pallet}, expected: []Load{
+			{Container: "pallet", Items: 10000, Contents: []Load{
+				{Container: "carton", Items: 5000, Packages: []int{5000}},
+				{Container: "carton", Items: 5000, Packages: []int{5000}},
+			}},
+			{Container: "pallet", Items: 5250, Contents: []Load{
+				{Container: "carton", Items: 5000, Packages: []int{5000}},
+				{Container: "carton", Items: 250, Packages: []int{250}},
+			}},
+		}},
+		{packages: []int{}, levels: []Container{carton, pallet}, expected: []Load{}},
+		{packages: []int{5000}, levels: []Container{{Name: "carton", Capacity: 4000}}, err: ErrPackTooLarge},
+		{packages: []int{5000}, levels: []Container{carton, {Name: "pallet"}}, err: ErrContainerInvalid},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			out, err := PlanLoad(test.packages, test.levels)
+
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, test.expected, out)
+		})
+	}
+}
+
+func TestPlanLoadFitsEveryPack(t *testing.T) {
+	repo := New()
+	levels := []Container{{Name: "carton", Capacity: 140}, {Name: "pallet", Capacity: 3}}
+	for order := 1; order <= 2000; order += 13 {
+		packages := repo.Calculate(packsNonDivisible, order)
+		out, err := PlanLoad(packages, levels)
+		assert.NoError(t, err)
+
+		// every pack is loaded exactly once, and no container is over capacity.
+		var loaded []int
+		for _, pallet := range out {
+			assert.LessOrEqual(t, len(pallet.Contents), 3)
+			for _, carton := range pallet.Contents {
+				assert.LessOrEqual(t, carton.Items, 140)
+				assert.Equal(t, sumSlice(carton.Packages), carton.Items)
+				loaded = append(loaded, carton.Packages...)
+			}
+		}
+		assert.ElementsMatch(t, packages, loaded, "order %d", order)
+	}
+}
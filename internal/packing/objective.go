@@ -0,0 +1,163 @@
+package packing
+
+import (
+	"cmp"
+	"fmt"
+)
+
+// Criterion is a single ranking rule applied to candidate distributions. Lower is better for all of them.
+type Criterion string
+
+const (
+	// CriterionItems ranks by the number of items sent, which is rule 2 from the README.
+	CriterionItems Criterion = "items"
+	// CriterionPacks ranks by the number of packs sent, which is rule 3 from the README.
+	CriterionPacks Criterion = "packs"
+	// CriterionCost ranks by the summed cost of the packs sent.
+	CriterionCost Criterion = "cost"
+)
+
+var (
+	ErrObjectiveInvalid = fmt.Errorf("objective contains an unknown or repeated criterion")
+	ErrCostMissing      = fmt.Errorf("cost objective requires a non-negative cost for every pack size")
+)
+
+// Objective is an ordered list of criteria, earlier criteria take precedence and later ones only break ties. Items and
+// packs are always appended as the final tiebreakers if missing, so an empty Objective ranks by rules 2 and 3.
+// Cost is only taken into account if it is part of the objective.
+type Objective []Criterion
+
+// ParseObjective converts a list of criterion names into an Objective.
+func ParseObjective(names []string) (Objective, error) {
+	out := make(Objective, 0, len(names))
+	for _, name := range names {
+		c := Criterion(name)
+		switch c {
+		case CriterionItems, CriterionPacks, CriterionCost:
+		default:
+			return nil, ErrObjectiveInvalid
+		}
+
+		if out.uses(c) {
+			return nil, ErrObjectiveInvalid
+		}
+
+		out = append(out, c)
+	}
+
+	return out, nil
+}
+
+// normalise returns the objective with the implicit tiebreakers appended.
+func (o Objective) normalise() Objective {
+	out := append(Objective{}, o...)
+	for _, c := range []Criterion{CriterionItems, CriterionPacks} {
+		if !out.uses(c) {
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+// uses reports whether c is part of the objective.
+func (o Objective) uses(c Criterion) bool {
+	for _, item := range o {
+		if item == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+// score is what a distribution adds up to for every criterion except items, which is the total itself.
+type score struct {
+	packs int
+	cost  int
+}
+
+func (s score) add(o score) score {
+	return score{packs: s.packs + o.packs, cost: s.cost + o.cost}
+}
+
+func (s score) scale(n int) score {
+	return score{packs: s.packs * n, cost: s.cost * n}
+}
+
+// compareScores compares a and b by every criterion except items, in order. It returns a negative number if a ranks
+// ahead of b, a positive one if b ranks ahead of a and zero if they are tied.
+func (o Objective) compareScores(a, b score) int {
+	for _, c := range o {
+		switch c {
+		case CriterionPacks:
+			if a.packs != b.packs {
+				return cmp.Compare(a.packs, b.packs)
+			}
+		case CriterionCost:
+			if a.cost != b.cost {
+				return cmp.Compare(a.cost, b.cost)
+			}
+		}
+	}
+
+	return 0
+}
+
+// compare compares two candidate totals and their scores by every criterion in order, with the same result convention
+// as compareScores.
+func (o Objective) compare(aTotal int, a score, bTotal int, b score) int {
+	for _, c := range o {
+		switch c {
+		case CriterionItems:
+			if aTotal != bTotal {
+				return cmp.Compare(aTotal, bTotal)
+			}
+		case CriterionPacks:
+			if a.packs != b.packs {
+				return cmp.Compare(a.packs, b.packs)
+			}
+		case CriterionCost:
+			if a.cost != b.cost {
+				return cmp.Compare(a.cost, b.cost)
+			}
+		}
+	}
+
+	return 0
+}
+
+// weights returns what a single pack of each size adds to a score. Costs are only looked up when the objective uses
+// them, so they don't affect tie breaking otherwise.
+func (o Objective) weights(packs []int, costs map[int]int) ([]score, error) {
+	out := make([]score, len(packs))
+	for i, pack := range packs {
+		out[i].packs = 1
+
+		if o.uses(CriterionCost) {
+			cost, ok := costs[pack]
+			if !ok || cost < 0 {
+				return nil, ErrCostMissing
+			}
+
+			out[i].cost = cost
+		}
+	}
+
+	return out, nil
+}
+
+// dominant returns the index of the pack with the best score per item. Covering the bulk of a large order with it never
+// ranks worse than using any other pack, which is what the bulk reduction in solve relies on. Ties go to the larger
+// pack, so the default objective always picks the largest one.
+func (o Objective) dominant(packs []int, weights []score) int {
+	best := 0
+	for i := 1; i < len(packs); i++ {
+		// comparing weights[i]/packs[i] with weights[best]/packs[best] without dividing.
+		if o.compareScores(weights[i].scale(packs[best]), weights[best].scale(packs[i])) <= 0 {
+			best = i
+		}
+	}
+
+	return best
+}
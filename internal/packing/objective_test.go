@@ -0,0 +1,136 @@
+package packing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseObjective(t *testing.T) {
+	tests := []struct {
+		names    []string
+		expected Objective
+		err      error
+	}{
+		{names: nil, expected: Objective{}},
+		{names: []string{"items", "cost"}, expected: Objective{CriterionItems, CriterionCost}},
+		{names: []string{"cost"}, expected: Objective{CriterionCost}},
+		{names: []string{"items", "items"}, err: ErrObjectiveInvalid},
+		{names: []string{"weight"}, err: ErrObjectiveInvalid},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			out, err := ParseObjective(test.names)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, out)
+		})
+	}
+}
+
+func TestSolveObjective(t *testing.T) {
+	// the 5000 pack is the cheapest per item, but 2000 packs are cheaper per pack than 2500 worth of smaller ones.
+	costs := map[int]int{250: 100, 500: 120, 1000: 150, 2000: 200, 5000: 300}
+
+	tests := []struct {
+		order     int
+		objective Objective
+		costs     map[int]int
+		expected  []int
+		err       error
+	}{
+		{order: 12001, objective: Objective{}, costs: costs, expected: []int{5000, 5000, 2000, 250}},
+		{order: 751, objective: Objective{CriterionItems, CriterionCost}, costs: costs, expected: []int{1000}},
+		// 3000 can be sent as 2000+1000 (350) or 2000+500+500 (440), or as one 5000 for less.
+		{order: 3000, objective: Objective{CriterionCost}, costs: costs, expected: []int{5000}},
+		{order: 3000, objective: Objective{CriterionItems, CriterionCost}, costs: costs, expected: []int{2000, 1000}},
+		{order: 3000, objective: Objective{CriterionPacks}, costs: costs, expected: []int{5000}},
+		{order: 3000, objective: Objective{CriterionCost}, costs: map[int]int{250: 1}, err: ErrCostMissing},
+		{order: 3000, objective: Objective{}, costs: nil, expected: []int{2000, 1000}},
+	}
+
+	repo := New()
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			res, err := repo.Solve(context.Background(), packs, test.order, Options{
+				Objective: test.objective,
+				Costs:     test.costs,
+			})
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, res.Packages)
+		})
+	}
+}
+
+func TestSolveObjectiveMatchesBruteForce(t *testing.T) {
+	set := []int{4, 7, 10}
+	costs := map[int]int{4: 5, 7: 6, 10: 11}
+	objectives := []Objective{
+		{CriterionCost},
+		{CriterionCost, CriterionPacks},
+		{CriterionItems, CriterionCost},
+		{CriterionPacks, CriterionItems},
+		{CriterionPacks, CriterionCost},
+	}
+
+	repo := New()
+	for _, objective := range objectives {
+		for order := 1; order <= 300; order++ {
+			res, err := repo.Solve(context.Background(), set, order, Options{Objective: objective, Costs: costs})
+			assert.NoError(t, err)
+
+			total, packCount, cost := bruteForceObjective(set, costs, objective.normalise(), order)
+			assert.Equal(t, total, sumSlice(res.Packages), "objective %v order %d", objective, order)
+			assert.Equal(t, packCount, len(res.Packages), "objective %v order %d", objective, order)
+			if objective.uses(CriterionCost) {
+				assert.Equal(t, cost, costOf(res.Packages, costs), "objective %v order %d", objective, order)
+			}
+		}
+	}
+}
+
+// bruteForceObjective tries every combination of three sizes up to target+largest and returns the best total, pack
+// count and cost under objective.
+func bruteForceObjective(packs []int, costs map[int]int, objective Objective, target int) (int, int, int) {
+	limit := target + packs[2]
+	bestTotal, best := -1, score{}
+	for a := 0; a*packs[0] <= limit; a++ {
+		for b := 0; a*packs[0]+b*packs[1] <= limit; b++ {
+			for c := 0; a*packs[0]+b*packs[1]+c*packs[2] <= limit; c++ {
+				total := a*packs[0] + b*packs[1] + c*packs[2]
+				if total < target {
+					continue
+				}
+
+				s := score{packs: a + b + c, cost: a*costs[packs[0]] + b*costs[packs[1]] + c*costs[packs[2]]}
+				if bestTotal == -1 || objective.compare(total, s, bestTotal, best) < 0 {
+					bestTotal, best = total, s
+				}
+			}
+		}
+	}
+
+	return bestTotal, best.packs, best.cost
+}
+
+// costOf returns the summed cost of a distribution.
+func costOf(distribution []int, costs map[int]int) int {
+	out := 0
+	for _, pack := range distribution {
+		out += costs[pack]
+	}
+
+	return out
+}
@@ -0,0 +1,80 @@
+package packing
+
+import (
+	"context"
+	"math"
+)
+
+// NoSolutionError is ErrNoSolution for an order whose overage caps can't be met. It carries the nearest distributions
+// that could be sent instead, either of them is nil if there is none.
+type NoSolutionError struct {
+	// Under is the best distribution of less than the order, Over the best one of the order or more without the caps.
+	Under []int
+	Over  []int
+}
+
+func (e *NoSolutionError) Error() string {
+	return ErrNoSolution.Error()
+}
+
+func (e *NoSolutionError) Unwrap() error {
+	return ErrNoSolution
+}
+
+// capped reports whether the options cap the overage on top of the strategy.
+func (pr *problem) capped() bool {
+	return pr.opts.ExactOnly || pr.opts.MaxOverage != nil || pr.opts.MaxOveragePercent != nil
+}
+
+// cap lowers hi, the top of the strategy's window for target, to the overage caps of the options.
+func (pr *problem) cap(target, hi int) int {
+	if pr.opts.ExactOnly {
+		hi = min(hi, target)
+	}
+
+	if n := pr.opts.MaxOverage; n != nil && *n <= math.MaxInt-target {
+		hi = min(hi, target+*n)
+	}
+
+	// a percentage is rounded down, since part of an item can't be sent.
+	if percent := pr.opts.MaxOveragePercent; percent != nil {
+		if n := float64(target) * *percent / 100; n < float64(math.MaxInt-target) {
+			hi = min(hi, target+int(n))
+		}
+	}
+
+	return hi
+}
+
+// nearest returns the error for target, an order whose overage caps can't be met. It lifts the caps and solves the
+// order once for sending less than it and once for sending at least it, both ranked by the objective of pr. Failing
+// to find either is not an error, running out of time is.
+func (p *Packager) nearest(ctx context.Context, pr *problem, target int) error {
+	opts := pr.opts
+	opts.Alternatives, opts.Explain, opts.OmitPackages = 0, false, false
+	opts.MaxOverage, opts.MaxOveragePercent, opts.ExactOnly = nil, nil, false
+	opts.Objective = pr.objective
+
+	out := &NoSolutionError{}
+	sides := []struct {
+		strategy Strategy
+		packages *[]int
+	}{
+		{strategy: underFulfil, packages: &out.Under},
+		{strategy: minItemsThenMinPacks, packages: &out.Over},
+	}
+
+	for _, side := range sides {
+		opts.Strategy = side.strategy
+		res, err := p.Solve(ctx, pr.packs, target, opts)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err == nil && len(res.Packages) > 0 {
+			*side.packages = res.Packages
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,55 @@
+package packing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolveOverage(t *testing.T) {
+	n := func(v int) *int { return &v }
+	percent := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		opts     Options
+		order    int
+		expected []int
+		under    []int
+		over     []int
+	}{
+		{opts: Options{MaxOverage: n(249)}, order: 751, expected: []int{1000}},
+		{opts: Options{MaxOverage: n(100)}, order: 751, under: []int{500, 250}, over: []int{1000}},
+		{opts: Options{MaxOveragePercent: percent(33.2)}, order: 751, expected: []int{1000}},
+		{opts: Options{MaxOveragePercent: percent(30)}, order: 751, under: []int{500, 250}, over: []int{1000}},
+		{opts: Options{ExactOnly: true}, order: 750, expected: []int{500, 250}},
+		{opts: Options{ExactOnly: true}, order: 751, under: []int{500, 250}, over: []int{1000}},
+		// nothing can be sent below the smallest pack.
+		{opts: Options{ExactOnly: true}, order: 100, over: []int{250}},
+		{opts: Options{ExactOnly: true}, order: 10_000_251, under: append(repeat(5000, 2000), 250),
+			over: append(repeat(5000, 2000), 500)},
+		// the caps only narrow the strategy's window.
+		{opts: Options{MaxOverage: n(1000), Strategy: windowStrategy{
+			objective: Objective{CriterionItems, CriterionPacks}, overage: 0, bounded: true,
+		}}, order: 751, under: []int{500, 250}, over: []int{1000}},
+		// the nearest distributions stay within stock.
+		{opts: Options{ExactOnly: true, Stock: map[int]int{250: 0}}, order: 750, under: []int{500}, over: []int{1000}},
+	}
+
+	for _, repo := range []*Packager{New(), NewCached(NewCache(1 << 20))} {
+		for i, test := range tests {
+			t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+				res, err := repo.Solve(context.Background(), packs, test.order, test.opts)
+				if test.expected == nil {
+					assert.ErrorIs(t, err, ErrNoSolution)
+					assert.Equal(t, &NoSolutionError{Under: test.under, Over: test.over}, err)
+					return
+				}
+
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, res.Packages)
+			})
+		}
+	}
+}
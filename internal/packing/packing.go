@@ -1,6 +1,9 @@
 package packing
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"maps"
 	"math"
 	"sort"
@@ -14,12 +17,18 @@ import (
 // Packager is a packaging repo.
 type Packager struct {
 	// dependency injections in here
+	cache *Cache
 }
 
 func New() *Packager {
 	return &Packager{}
 }
 
+// NewCached returns a Packager that reuses the tables held by cache instead of tabulating them for every order.
+func NewCached(cache *Cache) *Packager {
+	return &Packager{cache: cache}
+}
+
 // sumSlice returns the sum of the slice.
 func sumSlice(in []int) int {
 	sum := 0
@@ -30,7 +39,83 @@ func sumSlice(in []int) int {
 	return sum
 }
 
+// Calculate returns the best distribution of packs for target, sorted by reverse size.
 func (p *Packager) Calculate(packs []int, target int) []int {
+	// a background context is never done, so there is no error to handle here.
+	out, _ := p.CalculateContext(context.Background(), packs, target)
+
+	return out
+}
+
+// CalculateContext is Calculate bound to a context. The tabulation checks the context periodically and stops with the
+// context's error once it is done, so a request that timed out or was abandoned doesn't keep burning CPU.
+func (p *Packager) CalculateContext(ctx context.Context, packs []int, target int) ([]int, error) {
+	res, err := p.Solve(ctx, packs, target, Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Packages, nil
+}
+
+// Options tunes a single calculation. The zero value applies rules 1 to 3 with every pack size available in any number.
+type Options struct {
+	// Alternatives is the maximum number of equally good distributions returned next to the best one.
+	Alternatives int
+	// Stock limits how many packs of a size are available. Sizes missing from the map are unlimited.
+	Stock map[int]int
+	// Strategy decides which totals may be sent and how they are ranked by default. Nil applies DefaultStrategy.
+	Strategy Strategy
+	// Objective overrides the ranking of the strategy if it is not empty.
+	Objective Objective
+	// Costs holds the cost of a single pack per size. It is only required if Objective uses CriterionCost.
+	Costs map[int]int
+	// Explain asks for an explanation of why the distribution was chosen.
+	Explain bool
+	// Parcel caps every parcel of the result if it is set. Sizes that don't fit a parcel on their own are never sent,
+	// and the result is split into parcels.
+	Parcel *ParcelLimits
+	// Specs holds the weight and volume of a single pack per size. It is only required if Parcel is set.
+	Specs map[int]PackSpec
+	// MaxOverage caps the items sent above the order on top of the strategy's window, if it is set.
+	MaxOverage *int
+	// MaxOveragePercent caps the items sent above the order to a percentage of the order, if it is set.
+	MaxOveragePercent *float64
+	// ExactOnly only accepts distributions that add up to the order exactly.
+	ExactOnly bool
+	// MaxPacks caps the number of packs in the distribution, if it is positive.
+	MaxPacks int
+	// MaxTableBytes caps the memory of every table the calculation tabulates, if it is positive. Without it, a table
+	// goes up to 2^27 totals.
+	MaxTableBytes int
+	// OmitPackages leaves out Result.Packages and Result.Parcels, which list every pack on their own, so the result
+	// stays small no matter the order. Packs is filled in either way.
+	OmitPackages bool
+}
+
+// Result is the outcome of a calculation.
+type Result struct {
+	// Packages is the best distribution, sorted by reverse size.
+	Packages []int
+	// Packs is the same distribution as the number of packs per size, which stays small no matter the order.
+	Packs []PackQuantity
+	// Alternatives are other distributions that are exactly as good as Packages.
+	Alternatives [][]int
+	// Explanation is only filled in if it was asked for.
+	Explanation *Explanation
+	// Parcels splits Packages into parcels, it is only filled in if parcels are capped.
+	Parcels []Parcel
+}
+
+// Solve calculates the best distribution of packs for target under the given options. It returns ErrNoSolution if the
+// strategy doesn't accept any reachable total, ErrInsufficientStock if opts.Stock doesn't allow any distribution the
+// strategy accepts (strategies sending below the order send nothing instead if nothing is in stock), and
+// ErrCostMissing if the objective ranks by cost and opts.Costs lacks one of the sizes. With parcels capped, it returns
+// ErrSpecMissing if opts.Specs lacks one of the sizes and ErrNoPackFits if no size fits.
+// If the overage is capped by opts and nothing fits the cap, the error is a *NoSolutionError. Orders that would
+// overflow an int return ErrOrderTooLarge, distributions of more than opts.MaxPacks packs ErrTooManyPacks, unless
+// nothing in the result lists every pack.
+func (p *Packager) Solve(ctx context.Context, packs []int, target int, opts Options) (*Result, error) {
 	// in API, we don't need this, since we ensure this only happens once when new package sizes are created, but
 	// for completeness’s sake, if the tester runs this algorithm on its own with the API we do it here too.
 	// This would not have needed to be done in real world scenarios.
@@ -38,80 +123,287 @@ func (p *Packager) Calculate(packs []int, target int) []int {
 
 	// eliminate negative or zero. We do this in the API already, but as above for completeness's sake we do it here to.
 	if target <= 0 {
-		return []int{}
+		return &Result{Packages: []int{}, Packs: []PackQuantity{}}, nil
 	}
 
-	// if the order is smaller than the smallest package, then just return that.
-	if target < packs[0] {
-		return []int{packs[0]}
-	}
-
-	// initialize the difference checking vars
-	//  we will use these to first calculate the smallest difference in items (2. point)
-	//  then the smallest length (3. point) in case some of the results come up to the same sum
-	smallestDifference := math.MaxInt32
-	smallestLength := math.MaxInt32
-
-	// make an array to count boxes
-	boxes := make([]int, target+packs[len(packs)-1]+1)
-	// here we will create combinations
-	solutions := make([][]int, len(boxes))
-	for i := 0; i < len(boxes); i++ {
-		// make sure the size is 32bit, otherwise we will cause overflow and get negative value
-		boxes[i] = math.MaxInt32
-	}
-
-	// first box is always the 0th case, making the least viable solution to any order to send 0 boxes.
-	boxes[0] = 0
-	// here we will store all solutions, we could omit this, but then we would have to rebuild each solution dynamically
-	// aka. create a cartesian product for each row.
-	solutions[0] = make([]int, len(packs))
-
-	// we will store our output here
-	var closestMatch []int
-	for i := 1; i < len(boxes); i++ {
-		for j := 0; j < len(packs); j++ {
-			// dynamically look packs[j] behind if the box has already been accounted for.
-			// here we could also look ahead as per tabulation standard, but since we're building a list of combinations,
-			// looking behind is better since we can also take the existing array and just add instead of having to rebuild it.
-			if packs[j] <= i && boxes[i-packs[j]]+1 < boxes[i] {
-				// increment the number of boxes
-				boxes[i] = boxes[i-packs[j]] + 1
-
-				// reuse the existing array - memoization
-				if solutions[i] == nil {
-					solutions[i] = make([]int, len(packs))
-				}
-				for k := range packs {
-					solutions[i][k] = solutions[i-packs[j]][k]
-				}
+	pr, err := newProblem(packs, opts)
+	if errors.Is(err, ErrInsufficientStock) && below(opts.Strategy) {
+		// sending less than the order includes sending nothing at all, the whole order is backordered then.
+		return &Result{Packages: []int{}, Packs: []PackQuantity{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lo, hi, err := pr.window(target)
+	if err == nil {
+		if counts, ok := p.periodic(ctx, pr, target, lo); ok {
+			res, err := pr.newResult(counts)
+			if err != nil {
+				return nil, err
+			}
+
+			return pr.withParcels(res), nil
+		}
+	}
+
+	var sol *solution
+	if err == nil {
+		sol, err = solve(ctx, p.cache, pr.reduced, pr.weights, pr.objective, lo, hi, pr.opts.tableCells(), pr.below)
+	}
+	if errors.Is(err, ErrNoSolution) && pr.capped() {
+		return nil, p.nearest(ctx, pr, target)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pr.result(ctx, sol, target)
+}
+
+// problem is everything about a calculation that doesn't depend on the order, so it can be shared by many orders.
+type problem struct {
+	opts      Options
+	packs     []int // sizes in stock, sorted in ascending order
+	stock     []int // stock per size in packs
+	reduced   []int // packs divided by divisor
+	divisor   int
+	below     bool // totals are ranked for sending at most the order
+	strategy  Strategy
+	objective Objective // normalised
+	weights   []score
+}
+
+// newProblem prepares a calculation for packs, which must be sorted in ascending order.
+func newProblem(packs []int, opts Options) (*problem, error) {
+	pr := &problem{opts: opts, strategy: opts.Strategy}
+
+	// a size too heavy or too large for a parcel is as good as out of stock.
+	stock := opts.Stock
+	if opts.Parcel != nil {
+		var err error
+		stock, err = withinLimits(packs, stock, opts.Specs, *opts.Parcel)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// sizes that are out of stock can't be part of any distribution, so we drop them before solving.
+	pr.packs, pr.stock = inStock(packs, stock)
+	if len(pr.packs) == 0 && opts.Parcel != nil {
+		return nil, ErrNoPackFits
+	}
+	if len(pr.packs) == 0 {
+		return nil, ErrInsufficientStock
+	}
+
+	if pr.strategy == nil {
+		pr.strategy = minItemsThenMinPacks
+	}
+
+	pr.below = below(pr.strategy)
+
+	pr.objective = pr.strategy.Objective()
+	if len(opts.Objective) > 0 {
+		pr.objective = opts.Objective
+	}
+	pr.objective = pr.objective.normalise()
+
+	var err error
+	pr.weights, err = pr.objective.weights(pr.packs, opts.Costs)
+	if err != nil {
+		return nil, err
+	}
+
+	pr.reduced, pr.divisor = normalise(pr.packs)
+
+	return pr, nil
+}
+
+// window returns the range of reduced totals the strategy accepts for target, within the overage caps of the options.
+func (pr *problem) window(target int) (int, int, error) {
+	if err := pr.check(target); err != nil {
+		return 0, 0, err
+	}
+
+	lo, hi := pr.strategy.Window(target)
+
+	return pr.reduce(lo, pr.cap(target, hi))
+}
+
+// reduce turns the range of totals from lo to hi into reduced totals.
+func (pr *problem) reduce(lo, hi int) (int, int, error) {
+	// the window is rounded inwards, since only multiples of the divisor can be reached. Nothing past a largest pack
+	// above the bottom of the window can rank better than what's below it, since taking any pack off still fits. Below
+	// the order it is the other way round.
+	lo, hi = (lo+pr.divisor-1)/pr.divisor, hi/pr.divisor
+	if pr.below {
+		lo = max(lo, hi-pr.reduced[len(pr.reduced)-1]+1)
+	} else {
+		hi = min(hi, lo+pr.reduced[len(pr.reduced)-1]-1)
+	}
+	if lo > hi {
+		return 0, 0, ErrNoSolution
+	}
+
+	return lo, hi, nil
+}
+
+// result turns sol, the unlimited solution for target, into a Result. If it doesn't fit stock, the order is solved
+// again within stock.
+func (pr *problem) result(ctx context.Context, sol *solution, target int) (*Result, error) {
+	// the unlimited answer is also the best one within stock whenever stock allows it, which is the common case and
+	// keeps answers stable while the warehouse is well stocked.
+	if counts := sol.counts(); fits(counts, pr.stock) {
+		res, err := pr.newResult(counts)
+		if err != nil {
+			return nil, err
+		}
 
-				// add one current box
-				solutions[i][j]++
-
-				// sum the array in order to check whether the sum is smaller than the previous smaller sum.
-				s := sum(solutions[i], packs)
-				if s >= target && s <= smallestDifference {
-					// if diff is definitely smaller, we don't check for number of boxes
-					if s < smallestDifference {
-						smallestDifference = s
-						smallestLength = boxes[i]
-						closestMatch = remap(solutions[i], packs)
-						continue
-					}
-					// if diff is not definitely smaller (e.g. is equal), we check for number of boxes
-					if boxes[i] <= smallestLength {
-						smallestDifference = s
-						smallestLength = boxes[i]
-						closestMatch = remap(solutions[i], packs)
-					}
+		// alternatives tie on every criterion, the packs included, so they are no larger than packages.
+		if pr.opts.Alternatives > 0 {
+			for _, alternative := range sol.enumerate(pr.opts.Alternatives + 1)[1:] {
+				if fits(alternative, pr.stock) {
+					res.Alternatives = append(res.Alternatives, remap(alternative, pr.packs))
 				}
 			}
 		}
+
+		if pr.opts.Explain {
+			res.Explanation = sol.explain(pr.packs, pr.divisor, target)
+		}
+
+		return pr.withParcels(res), nil
+	}
+
+	shift := sol.bulk * pr.reduced[sol.dominant]
+	lo := sol.lo + shift
+	if pr.below {
+		// a pack can only be added if there's one left in stock, so the closest total below the order may be far below.
+		lo = 0
+	}
+
+	cells := pr.opts.tableCells()
+	sol, err := solveBounded(ctx, pr.reduced, pr.weights, pr.objective, pr.stock, lo, sol.hi+shift, cells, pr.below)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := pr.newResult(sol.counts())
+	if err != nil {
+		return nil, err
+	}
+
+	if pr.opts.Explain {
+		res.Explanation = sol.explain(pr.packs, pr.divisor, target)
+	}
+
+	return pr.withParcels(res), nil
+}
+
+// withParcels splits the packages of res into parcels, if parcels are capped and packages aren't omitted.
+func (pr *problem) withParcels(res *Result) *Result {
+	if pr.opts.Parcel != nil && !pr.opts.OmitPackages {
+		res.Parcels = splitParcels(res.Packages, pr.opts.Specs, *pr.opts.Parcel)
+	}
+
+	return res
+}
+
+// normalise divides packs by their common divisor. Every total we can reach is a multiple of it, so we solve the
+// problem in units of that divisor and map the counts back onto the original sizes. For the default config this makes
+// the table 250x smaller. Counts are per index, so they don't need scaling.
+func normalise(packs []int) ([]int, int) {
+	divisor := packs[0]
+	for _, pack := range packs[1:] {
+		divisor = gcd(divisor, pack)
+	}
+
+	reduced := make([]int, len(packs))
+	for i, pack := range packs {
+		reduced[i] = pack / divisor
+	}
+
+	return reduced, divisor
+}
+
+// PackQuantity is the number of packs of a single size in a distribution.
+type PackQuantity struct {
+	Size     int
+	Quantity int
+}
+
+// solution is a solved order. Only the remainder of the order is tabulated, the rest is covered by bulk dominant packs.
+type solution struct {
+	table    *table
+	total    int // best total of the remainder
+	lo       int // bottom of the window of the remainder
+	hi       int // top of the window of the remainder
+	bulk     int // number of dominant packs set aside
+	dominant int // index of the dominant pack
+	below    bool
+}
+
+// solve finds the best distribution whose total lies between lo and hi. packs must be sorted in ascending order, lo
+// must not be negative, hi must be less than a largest pack above lo and objective must be normalised. The table is
+// taken from cache, which may be nil, and holds at most cells totals. below ranks the totals for sending at most the
+// order, see table.best.
+func solve(
+	ctx context.Context, cache *Cache, packs []int, weights []score, objective Objective, lo, hi, cells int, below bool,
+) (*solution, error) {
+	d := objective.dominant(packs, weights)
+	bulk := setAside(packs, d, lo)
+	shift := bulk * packs[d]
+	if hi-shift >= cells {
+		return nil, fmt.Errorf("%w: %d totals", ErrTableTooLarge, hi-shift)
 	}
 
-	// we return the closest match
-	return closestMatch
+	t, err := cache.table(ctx, packs, weights, objective, hi-shift)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.solution(lo-shift, hi-shift, bulk, d, below)
+}
+
+// setAside returns how many dominant packs d can be set aside for a window starting at lo.
+//
+// Tabulating every total up to the order makes memory grow with the order itself, which large orders can't afford.
+// Above the reduction bound the best distribution contains the dominant pack (the largest one, unless costs say
+// otherwise), and taking one off the top leaves the best distribution of the rest, so we set aside as many of them as
+// we safely can and only solve the remainder. Every total in the window shifts by the same amount, so they still rank
+// the same. This keeps the table within bound+dominant+largest cells no matter how large the order is.
+func setAside(packs []int, d, lo int) int {
+	bound := reductionBound(packs, d)
+	if lo <= bound {
+		return 0
+	}
+
+	return (lo - bound - 1) / packs[d]
+}
+
+// solution returns the best distribution of a window from lo to hi of the table, which has bulk dominant packs d set
+// aside. It returns ErrNoSolution if no total in the window is reachable.
+func (t *table) solution(lo, hi, bulk, d int, below bool) (*solution, error) {
+	total := t.best(lo, hi, below)
+	if total == -1 {
+		return nil, ErrNoSolution
+	}
+
+	return &solution{table: t, total: total, lo: lo, hi: hi, bulk: bulk, dominant: d, below: below}, nil
+}
+
+// counts returns the number of repetitions of each pack in the best distribution.
+func (s *solution) counts() []int {
+	return s.countsOf(s.total)
+}
+
+// countsOf returns the number of repetitions of each pack in the best distribution of total, a total of the remainder.
+func (s *solution) countsOf(total int) []int {
+	counts := s.table.counts(total)
+	counts[s.dominant] += s.bulk
+
+	return counts
 }
 
 // remap function changes the working array (len(packs), with number of repetitions for each package), to array with
@@ -131,12 +423,49 @@ func remap(arr, packs []int) []int {
 	return out
 }
 
-// sum the array of repetitions, so we can calculate the smallest positive difference to the target.
-// example: packs = [2, 3], arr = [1, 4], algo = 1*2 + 3*4, output = 14
-func sum(arr []int, packs []int) int {
-	out := 0
-	for i, item := range arr {
-		out += item * packs[i]
+// Ungroup lists every pack of packs on its own, largest first like Result.Packages, or returns ErrTooManyPacks if they
+// are more than maxPacks, unless maxPacks is 0.
+// example: packs = [{30, 2}, {5, 1}], output = [30, 30, 5]
+func Ungroup(packs []PackQuantity, maxPacks int) ([]int, error) {
+	n := 0
+	for _, pack := range packs {
+		n += pack.Quantity
+	}
+
+	if maxPacks > 0 && n > maxPacks {
+		return nil, fmt.Errorf("%w: %d packs, at most %d", ErrTooManyPacks, n, maxPacks)
+	}
+
+	out := make([]int, 0, n)
+	for _, pack := range packs {
+		for j := pack.Quantity; j > 0; j-- {
+			out = append(out, pack.Size)
+		}
+	}
+
+	return out, nil
+}
+
+// itemsOf returns the number of items in packs.
+func itemsOf(packs []PackQuantity) int {
+	items := 0
+	for _, pack := range packs {
+		items += pack.Size * pack.Quantity
+	}
+
+	return items
+}
+
+// group changes the working array into the number of packs per size, sorted by reverse size and leaving out the sizes
+// that aren't used. Unlike remap, it doesn't grow with the order.
+// example: packs = [5, 10, 20, 30], arr = [1, 0, 3, 4], output = [{30, 4}, {20, 3}, {5, 1}]
+func group(arr, packs []int) []PackQuantity {
+	out := []PackQuantity{}
+
+	for i := len(arr) - 1; i >= 0; i-- {
+		if arr[i] > 0 {
+			out = append(out, PackQuantity{Size: packs[i], Quantity: arr[i]})
+		}
 	}
 
 	return out
@@ -203,8 +532,8 @@ func getLeastShortest(arr [][]int, target int) []int {
 		sums[sumSlice(item)] = append(sums[sumSlice(item)], item)
 	}
 
-	closestHigher := math.MaxInt32
-	idx := math.MaxInt32
+	closestHigher := math.MaxInt
+	idx := math.MaxInt
 	for k := range sums {
 		diff := k - target
 		if diff >= 0 && diff < closestHigher {
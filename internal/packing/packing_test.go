@@ -1,8 +1,10 @@
 package packing
 
 import (
+	"context"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"math"
 	"os"
 	"sort"
 	"testing"
@@ -56,6 +58,125 @@ func TestAlgorithm(t *testing.T) {
 	}
 }
 
+func TestAlgorithmLargeOrder(t *testing.T) {
+	// an order this size used to allocate a full combination per item, now it only needs the remainder window.
+	repo := New()
+	out := repo.Calculate([]int{250, 500, 1000, 2000, 5000}, 50_000_001)
+
+	assert.Len(t, out, 10_001)
+	assert.Equal(t, 50_000_250, sumSlice(out))
+	assert.Equal(t, []int{5000, 250}, out[len(out)-2:])
+}
+
+func TestSolvePacks(t *testing.T) {
+	res, err := New().Solve(context.Background(), []int{250, 500, 1000, 2000, 5000}, 50_000_001, Options{})
+	assert.NoError(t, err)
+
+	// the same distribution as Packages, without listing every pack.
+	assert.Equal(t, []PackQuantity{{Size: 5000, Quantity: 10_000}, {Size: 250, Quantity: 1}}, res.Packs)
+	assert.Len(t, res.Packages, 10_001)
+}
+
+func TestAlgorithmMatchesFullTable(t *testing.T) {
+	sets := [][]int{
+		{250, 500, 1000, 2000, 5000},
+		{11, 34, 59, 70},
+		{3, 4, 5},
+		{3, 5},
+		{7, 9, 23},
+	}
+
+	repo := New()
+	for _, set := range sets {
+		for order := 1; order <= 3000; order++ {
+			expected := calculateFullTable(append([]int{}, set...), order)
+			actual := repo.Calculate(append([]int{}, set...), order)
+
+			// ties between equally good distributions may resolve differently, the rules only care about these two.
+			assert.Equal(t, sumSlice(expected), sumSlice(actual), "packs %v order %d", set, order)
+			assert.Equal(t, len(expected), len(actual), "packs %v order %d", set, order)
+		}
+	}
+}
+
+func TestAlgorithmCommonDivisor(t *testing.T) {
+	sets := [][]int{
+		{250, 500, 1000, 2000, 5000},
+		{6, 9, 15},
+		{4, 10},
+	}
+
+	repo := New()
+	for _, set := range sets {
+		for order := 1; order <= 12000; order += 7 {
+			// solving the unreduced sizes directly must give exactly the same distribution.
+			objective := Objective{}.normalise()
+			weights, _ := objective.weights(set, nil)
+			hi := order + set[len(set)-1] - 1
+			sol, err := solve(context.Background(), nil, set, weights, objective, order, hi, defaultTableCells, false)
+			assert.NoError(t, err)
+			expected := remap(sol.counts(), set)
+			actual := repo.Calculate(append([]int{}, set...), order)
+
+			assert.Equal(t, expected, actual, "packs %v order %d", set, order)
+		}
+	}
+}
+
+// calculateFullTable is the previous implementation of Calculate, which tabulates every total up to the order and keeps
+// a full combination per total. It is kept here as a reference for the bounded implementation.
+func calculateFullTable(packs []int, target int) []int {
+	sort.Ints(packs)
+	if target < packs[0] {
+		return []int{packs[0]}
+	}
+
+	smallestDifference := math.MaxInt32
+	smallestLength := math.MaxInt32
+
+	boxes := make([]int, target+packs[len(packs)-1]+1)
+	solutions := make([][]int, len(boxes))
+	for i := 0; i < len(boxes); i++ {
+		boxes[i] = math.MaxInt32
+	}
+	boxes[0] = 0
+	solutions[0] = make([]int, len(packs))
+
+	var closestMatch []int
+	for i := 1; i < len(boxes); i++ {
+		for j := 0; j < len(packs); j++ {
+			if packs[j] <= i && boxes[i-packs[j]]+1 < boxes[i] {
+				boxes[i] = boxes[i-packs[j]] + 1
+				if solutions[i] == nil {
+					solutions[i] = make([]int, len(packs))
+				}
+				copy(solutions[i], solutions[i-packs[j]])
+				solutions[i][j]++
+
+				if i >= target && i <= smallestDifference && (i < smallestDifference || boxes[i] <= smallestLength) {
+					smallestDifference = i
+					smallestLength = boxes[i]
+					closestMatch = remap(solutions[i], packs)
+				}
+			}
+		}
+	}
+
+	return closestMatch
+}
+
+func TestCalculateContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// coprime sizes, so neither the common divisor nor the bulk reduction can make this table small.
+	repo := New()
+	out, err := repo.CalculateContext(ctx, []int{9_999, 10_000}, 100_000_000)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, out)
+}
+
 func TestGetLeastShortest(t *testing.T) {
 	tests := []struct {
 		arr      [][]int
@@ -0,0 +1,93 @@
+package packing
+
+import (
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrSpecMissing = fmt.Errorf("pack size has no weight and volume configured")
+	ErrNoPackFits  = fmt.Errorf("no pack size fits within the parcel limits")
+)
+
+// PackSpec is the weight and volume of a single pack of a size.
+type PackSpec struct {
+	Weight int
+	Volume int
+}
+
+// ParcelLimits caps a single parcel, as carriers do. A limit of 0 doesn't cap anything.
+type ParcelLimits struct {
+	Weight int
+	Volume int
+}
+
+// Parcel is a single parcel of a result, with its totals.
+type Parcel struct {
+	Packages []int
+	Weight   int
+	Volume   int
+}
+
+// fits reports whether a parcel of weight and volume stays within l.
+func (l ParcelLimits) fits(weight, volume int) bool {
+	return (l.Weight == 0 || weight <= l.Weight) && (l.Volume == 0 || volume <= l.Volume)
+}
+
+// withinLimits returns stock with every size that can't be sent on its own set to zero, so solving never picks it.
+// packs must be sorted in ascending order.
+func withinLimits(packs []int, stock map[int]int, specs map[int]PackSpec, limits ParcelLimits) (map[int]int, error) {
+	out := make(map[int]int, len(stock))
+	for size, n := range stock {
+		out[size] = n
+	}
+
+	for _, pack := range packs {
+		spec, ok := specs[pack]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrSpecMissing, pack)
+		}
+
+		if !limits.fits(spec.Weight, spec.Volume) {
+			out[pack] = 0
+		}
+	}
+
+	return out, nil
+}
+
+// splitParcels splits packages into parcels within limits. Every pack must fit on its own, see withinLimits.
+//
+// Like PlanLoad, this is first fit decreasing, with the heaviest packs going first and both the weight and the volume
+// having to fit.
+func splitParcels(packages []int, specs map[int]PackSpec, limits ParcelLimits) []Parcel {
+	packs := append([]int(nil), packages...)
+	sort.SliceStable(packs, func(i, j int) bool {
+		a, b := specs[packs[i]], specs[packs[j]]
+		if a.Weight != b.Weight {
+			return a.Weight > b.Weight
+		}
+
+		return a.Volume > b.Volume
+	})
+
+	parcels := []Parcel{}
+	for _, pack := range packs {
+		spec := specs[pack]
+
+		i := 0
+		for i < len(parcels) && !limits.fits(parcels[i].Weight+spec.Weight, parcels[i].Volume+spec.Volume) {
+			i++
+		}
+
+		if i == len(parcels) {
+			parcels = append(parcels, Parcel{})
+		}
+
+		parcels[i].Packages = append(parcels[i].Packages, pack)
+		parcels[i].Weight += spec.Weight
+		parcels[i].Volume += spec.Volume
+	}
+
+	return parcels
+}
@@ -0,0 +1,88 @@
+package packing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolveParcels(t *testing.T) {
+	specs := map[int]PackSpec{
+		250:  {Weight: 300, Volume: 1000},
+		500:  {Weight: 550, Volume: 2000},
+		1000: {Weight: 1100, Volume: 4000},
+		2000: {Weight: 2200, Volume: 8000},
+		5000: {Weight: 5500, Volume: 20000},
+	}
+
+	tests := []struct {
+		order    int
+		limits   ParcelLimits
+		specs    map[int]PackSpec
+		expected *Result
+		err      error
+	}{
+		{order: 751, limits: ParcelLimits{Weight: 10000}, specs: specs, expected: &Result{
+			Packages: []int{1000},
+			Packs:    []PackQuantity{{Size: 1000, Quantity: 1}},
+			Parcels:  []Parcel{{Packages: []int{1000}, Weight: 1100, Volume: 4000}},
+		}},
+		// the 5000 is over the weight limit, so the order is sent in 2000s.
+		{order: 5000, limits: ParcelLimits{Weight: 5000}, specs: specs, expected: &Result{
+			Packages: []int{2000, 2000, 1000},
+			Packs:    []PackQuantity{{Size: 2000, Quantity: 2}, {Size: 1000, Quantity: 1}},
+			Parcels: []Parcel{
+				{Packages: []int{2000, 2000}, Weight: 4400, Volume: 16000},
+				{Packages: []int{1000}, Weight: 1100, Volume: 4000},
+			},
+		}},
+		// volume fills up before weight does.
+		{order: 1250, limits: ParcelLimits{Weight: 10000, Volume: 4000}, specs: specs, expected: &Result{
+			Packages: []int{1000, 250},
+			Packs:    []PackQuantity{{Size: 1000, Quantity: 1}, {Size: 250, Quantity: 1}},
+			Parcels: []Parcel{
+				{Packages: []int{1000}, Weight: 1100, Volume: 4000},
+				{Packages: []int{250}, Weight: 300, Volume: 1000},
+			},
+		}},
+		{order: 1, limits: ParcelLimits{}, specs: specs, expected: &Result{
+			Packages: []int{250},
+			Packs:    []PackQuantity{{Size: 250, Quantity: 1}},
+			Parcels:  []Parcel{{Packages: []int{250}, Weight: 300, Volume: 1000}},
+		}},
+		{order: 1, limits: ParcelLimits{Weight: 100}, specs: specs, err: ErrNoPackFits},
+		{order: 1, limits: ParcelLimits{Weight: 100}, specs: map[int]PackSpec{250: {}}, err: ErrSpecMissing},
+	}
+
+	repo := New()
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			out, err := repo.Solve(context.Background(), packs, test.order, Options{Parcel: &test.limits, Specs: test.specs})
+
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, test.expected, out)
+		})
+	}
+}
+
+func TestSplitParcelsWithinLimits(t *testing.T) {
+	specs := map[int]PackSpec{11: {Weight: 11, Volume: 30}, 34: {Weight: 40, Volume: 60}, 59: {Weight: 50, Volume: 100},
+		70: {Weight: 90, Volume: 90}}
+	limits := ParcelLimits{Weight: 200, Volume: 250}
+
+	repo := New()
+	for order := 1; order <= 3000; order += 7 {
+		packages := repo.Calculate(packsNonDivisible, order)
+		parcels := splitParcels(packages, specs, limits)
+
+		var sent []int
+		for _, parcel := range parcels {
+			assert.LessOrEqual(t, parcel.Weight, limits.Weight)
+			assert.LessOrEqual(t, parcel.Volume, limits.Volume)
+			sent = append(sent, parcel.Packages...)
+		}
+		assert.ElementsMatch(t, packages, sent, "order %d", order)
+	}
+}
@@ -0,0 +1,146 @@
+package packing
+
+import (
+	"context"
+	"fmt"
+)
+
+// ErrInsufficientStock is returned when the packs in stock can't cover the order.
+var ErrInsufficientStock = fmt.Errorf("no combination of packs in stock satisfies the order")
+
+// unlimited marks a pack size without a stock limit.
+const unlimited = -1
+
+// inStock returns the sizes of packs that are available together with their stock limit, in the same order. packs must
+// be sorted in ascending order. Sizes missing from stock are unlimited, sizes with nothing left are dropped.
+func inStock(packs []int, stock map[int]int) ([]int, []int) {
+	available := make([]int, 0, len(packs))
+	limits := make([]int, 0, len(packs))
+	for _, pack := range packs {
+		n, ok := stock[pack]
+		if !ok {
+			n = unlimited
+		}
+
+		if n == 0 {
+			continue
+		}
+
+		available = append(available, pack)
+		limits = append(limits, n)
+	}
+
+	return available, limits
+}
+
+// fits reports whether the repetitions in counts are all within stock.
+func fits(counts, stock []int) bool {
+	for i, n := range counts {
+		if stock[i] != unlimited && n > stock[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// item is a bundle of count packs of the same size, used by solveBounded.
+type item struct {
+	index int
+	count int
+}
+
+// solveBounded finds the best distribution with a total between lo and hi that stays within stock. packs must be
+// sorted in ascending order and lo and hi must be set up as for solve, except that lo is 0 for below.
+//
+// With limited stock, a total above some bound no longer has to contain the largest pack, so the bulk reduction of
+// solve doesn't apply and we tabulate up to the whole order. Each size is split into bundles of 1, 2, 4, ... packs that
+// add up to its stock, which turns the problem into a 0/1 knapsack with only a logarithmic number of bundles per size.
+// Every bundle keeps one bit per total recording whether it was taken, which is all we need to walk the solution back.
+// The table holds at most cells totals.
+func solveBounded(
+	ctx context.Context, packs []int, weights []score, objective Objective, stock []int, lo, hi, cells int, below bool,
+) (*solution, error) {
+	// without unlimited sizes, we can't go past what's in stock.
+	limit := hi
+	capacity, bounded := 0, true
+	for i, pack := range packs {
+		if stock[i] == unlimited {
+			bounded = false
+			break
+		}
+
+		capacity += stock[i] * pack
+	}
+
+	if bounded && capacity < lo {
+		return nil, ErrInsufficientStock
+	}
+
+	if bounded && capacity < limit {
+		limit = capacity
+	}
+
+	if limit >= cells {
+		return nil, fmt.Errorf("%w: %d totals", ErrTableTooLarge, limit)
+	}
+
+	var items []item
+	for i, pack := range packs {
+		n := limit / pack
+		if stock[i] != unlimited && stock[i] < n {
+			n = stock[i]
+		}
+
+		for size := 1; n > 0; size *= 2 {
+			size = min(size, n)
+			items = append(items, item{index: i, count: size})
+			n -= size
+		}
+	}
+
+	// the back-pointers of the table are replaced by the bits below.
+	t := &table{
+		packs:     packs,
+		weights:   weights,
+		objective: objective,
+		scores:    make([]score, limit+1),
+		bundles:   items,
+		taken:     make([][]uint64, len(items)),
+	}
+	for i := 1; i <= limit; i++ {
+		t.scores[i] = score{packs: unreachable}
+	}
+
+	for k, it := range items {
+		t.taken[k] = make([]uint64, limit/64+1)
+		size := it.count * packs[it.index]
+		weight := weights[it.index].scale(it.count)
+
+		// going downwards makes sure every bundle is used at most once per total.
+		for i := limit; i >= size; i-- {
+			if i%cancelCheckInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+			}
+
+			if !t.reachable(i - size) {
+				continue
+			}
+
+			candidate := t.scores[i-size].add(weight)
+			if !t.reachable(i) || objective.compareScores(candidate, t.scores[i]) < 0 {
+				t.scores[i] = candidate
+				t.taken[k][i/64] |= 1 << (i % 64)
+			}
+		}
+	}
+
+	total := t.best(lo, limit, below)
+	if total == -1 {
+		return nil, ErrInsufficientStock
+	}
+
+	return &solution{table: t, total: total, lo: lo, hi: limit, below: below}, nil
+}
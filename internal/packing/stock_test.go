@@ -0,0 +1,103 @@
+package packing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolveStock(t *testing.T) {
+	tests := []struct {
+		packs    []int
+		order    int
+		stock    map[int]int
+		expected []int
+		err      error
+	}{
+		// stock doesn't bind, so the answer is the same as without it.
+		{packs: packs, order: 12001, stock: map[int]int{5000: 2}, expected: []int{5000, 5000, 2000, 250}},
+		{packs: packs, order: 12001, stock: map[int]int{5000: 1}, expected: []int{5000, 2000, 2000, 2000, 1000, 250}},
+		{packs: packs, order: 251, stock: map[int]int{500: 0}, expected: []int{250, 250}},
+		{packs: packs, order: 1, stock: map[int]int{250: 0}, expected: []int{500}},
+		{packs: packs, order: 751, stock: map[int]int{1000: 0, 2000: 0, 5000: 0, 500: 1, 250: 1}, err: ErrInsufficientStock},
+		{packs: packs, order: 1, stock: map[int]int{250: 0, 500: 0, 1000: 0, 2000: 0, 5000: 0}, err: ErrInsufficientStock},
+		{packs: packsSmall, order: 10, stock: map[int]int{5: 1}, expected: []int{4, 3, 3}},
+	}
+
+	repo := New()
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			res, err := repo.Solve(context.Background(), test.packs, test.order, Options{Stock: test.stock})
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, res.Packages)
+		})
+	}
+}
+
+func TestSolveBoundedMatchesBruteForce(t *testing.T) {
+	set := []int{3, 5, 8}
+	objective := Objective{}.normalise()
+	weights, _ := objective.weights(set, nil)
+	for a := 0; a <= 3; a++ {
+		for b := 0; b <= 3; b++ {
+			for c := 0; c <= 3; c++ {
+				stock := []int{a, b, c}
+				for order := 1; order <= 50; order++ {
+					total, boxes := bruteForceBounded(set, stock, order)
+					hi := order + set[2] - 1
+					sol, err := solveBounded(
+						context.Background(), set, weights, objective, stock, order, hi, defaultTableCells, false,
+					)
+					if total == -1 {
+						assert.ErrorIs(t, err, ErrInsufficientStock, "stock %v order %d", stock, order)
+						continue
+					}
+
+					assert.NoError(t, err)
+					counts := sol.counts()
+					assert.True(t, fits(counts, stock), "stock %v order %d", stock, order)
+					assert.Equal(t, total, sum(counts, set), "stock %v order %d", stock, order)
+					assert.Equal(t, boxes, sumSlice(counts), "stock %v order %d", stock, order)
+				}
+			}
+		}
+	}
+}
+
+// bruteForceBounded tries every combination within stock and returns the closest total and its fewest boxes.
+func bruteForceBounded(packs, stock []int, target int) (int, int) {
+	bestTotal, bestBoxes := -1, 0
+	for a := 0; a <= stock[0]; a++ {
+		for b := 0; b <= stock[1]; b++ {
+			for c := 0; c <= stock[2]; c++ {
+				total := a*packs[0] + b*packs[1] + c*packs[2]
+				if total < target {
+					continue
+				}
+
+				if bestTotal == -1 || total < bestTotal || (total == bestTotal && a+b+c < bestBoxes) {
+					bestTotal, bestBoxes = total, a+b+c
+				}
+			}
+		}
+	}
+
+	return bestTotal, bestBoxes
+}
+
+// sum returns the number of items in a distribution given as repetitions per pack.
+func sum(counts, packs []int) int {
+	out := 0
+	for i, n := range counts {
+		out += n * packs[i]
+	}
+
+	return out
+}
@@ -0,0 +1,181 @@
+package packing
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultStrategy is the strategy implementing rules 1 to 3 from the README.
+const DefaultStrategy = "min-items-then-min-packs"
+
+var (
+	ErrStrategyUnknown = fmt.Errorf("unknown strategy")
+	ErrNoSolution      = fmt.Errorf("no distribution satisfies the strategy")
+)
+
+// Strategy is a fulfilment policy. It decides which totals may be sent for an order and how they are ranked, so product
+// lines with different policies can share the same engine.
+type Strategy interface {
+	// Name is the name the strategy is selected by.
+	Name() string
+	// Window returns the inclusive range of totals that may be sent for target. A window that is open at the top returns
+	// math.MaxInt, the engine never looks further than a largest pack past lo since nothing there can rank better.
+	Window(target int) (lo, hi int)
+	// Objective ranks the totals inside the window, unless the calculation asks for a different objective.
+	Objective() Objective
+}
+
+// BelowStrategy is implemented by strategies that may send less than the order. Their window ends at the order, and
+// since the larger totals are the closer ones there, the items criterion ranks them first.
+type BelowStrategy interface {
+	Strategy
+	// Below reports whether the window lies below the order.
+	Below() bool
+}
+
+// below reports whether s is a BelowStrategy that lies below the order.
+func below(s Strategy) bool {
+	b, ok := s.(BelowStrategy)
+	return ok && b.Below()
+}
+
+// StrategyFactory builds a strategy from the parameter that follows its registered name, e.g. "25" for
+// "max-overage-25". Strategies selected by their plain name are given an empty parameter.
+type StrategyFactory func(param string) (Strategy, error)
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]StrategyFactory{}
+)
+
+// minItemsThenMinPacks implements rules 1 to 3, it is used whenever no strategy is given.
+var minItemsThenMinPacks = windowStrategy{
+	name:      DefaultStrategy,
+	objective: Objective{CriterionItems, CriterionPacks},
+}
+
+// underFulfil sends the closest total at or below the order, then the fewest packs, which is rule 1 turned around for
+// when stock is short and the rest of the order is backordered.
+var underFulfil = windowStrategy{
+	name:      "under-fulfil",
+	objective: Objective{CriterionItems, CriterionPacks},
+	below:     true,
+}
+
+func init() {
+	RegisterStrategy(DefaultStrategy, fixed(minItemsThenMinPacks))
+	RegisterStrategy("min-packs-then-min-items", fixed(windowStrategy{
+		name:      "min-packs-then-min-items",
+		objective: Objective{CriterionPacks, CriterionItems},
+	}))
+	RegisterStrategy("exact-or-fail", fixed(windowStrategy{
+		name:      "exact-or-fail",
+		objective: Objective{CriterionItems, CriterionPacks},
+		overage:   0,
+		bounded:   true,
+	}))
+	RegisterStrategy("under-fulfil", fixed(underFulfil))
+	RegisterStrategy("max-overage", func(param string) (Strategy, error) {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%w: max-overage needs a non-negative number, e.g. max-overage-100", ErrStrategyUnknown)
+		}
+
+		return windowStrategy{
+			name:      "max-overage-" + param,
+			objective: Objective{CriterionItems, CriterionPacks},
+			overage:   n,
+			bounded:   true,
+		}, nil
+	})
+}
+
+// RegisterStrategy makes a strategy available under name. Registering the same name twice replaces the first one.
+func RegisterStrategy(name string, factory StrategyFactory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	registry[name] = factory
+}
+
+// LookupStrategy returns the strategy selected by name. A name is either registered as is, or a registered name
+// followed by a dash and a parameter.
+func LookupStrategy(name string) (Strategy, error) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	if factory, ok := registry[name]; ok {
+		return factory("")
+	}
+
+	if i := strings.LastIndex(name, "-"); i != -1 {
+		if factory, ok := registry[name[:i]]; ok {
+			return factory(name[i+1:])
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrStrategyUnknown, name)
+}
+
+// Strategies returns the registered strategy names in alphabetical order.
+func Strategies() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	out := make([]string, 0, len(registry))
+	for name := range registry {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+// fixed returns a factory for a strategy that takes no parameter.
+func fixed(s Strategy) StrategyFactory {
+	return func(param string) (Strategy, error) {
+		if param != "" {
+			return nil, fmt.Errorf("%w: %s does not take a parameter", ErrStrategyUnknown, s.Name())
+		}
+
+		return s, nil
+	}
+}
+
+// windowStrategy covers the built in strategies, which accept the order or more, optionally capped by an overage, or
+// anything up to the order if they are below it.
+type windowStrategy struct {
+	name      string
+	objective Objective
+	overage   int
+	bounded   bool
+	below     bool
+}
+
+func (s windowStrategy) Name() string {
+	return s.name
+}
+
+func (s windowStrategy) Window(target int) (int, int) {
+	if s.below {
+		return 0, target
+	}
+
+	if !s.bounded || s.overage > math.MaxInt-target {
+		return target, math.MaxInt
+	}
+
+	return target, target + s.overage
+}
+
+func (s windowStrategy) Objective() Objective {
+	return s.objective
+}
+
+func (s windowStrategy) Below() bool {
+	return s.below
+}
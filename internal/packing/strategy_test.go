@@ -0,0 +1,149 @@
+package packing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		err      error
+	}{
+		{name: DefaultStrategy, expected: DefaultStrategy},
+		{name: "min-packs-then-min-items", expected: "min-packs-then-min-items"},
+		{name: "exact-or-fail", expected: "exact-or-fail"},
+		{name: "max-overage-100", expected: "max-overage-100"},
+		{name: "under-fulfil", expected: "under-fulfil"},
+		{name: "max-overage", err: ErrStrategyUnknown},
+		{name: "max-overage--1", err: ErrStrategyUnknown},
+		{name: "exact-or-fail-5", err: ErrStrategyUnknown},
+		{name: "cheapest", err: ErrStrategyUnknown},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			s, err := LookupStrategy(test.name)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, s.Name())
+		})
+	}
+}
+
+func TestSolveStrategy(t *testing.T) {
+	tests := []struct {
+		strategy string
+		order    int
+		expected []int
+		err      error
+	}{
+		{strategy: DefaultStrategy, order: 749, expected: []int{500, 250}},
+		{strategy: "min-packs-then-min-items", order: 749, expected: []int{1000}},
+		{strategy: "min-packs-then-min-items", order: 5001, expected: []int{5000, 250}},
+		{strategy: "exact-or-fail", order: 750, expected: []int{500, 250}},
+		{strategy: "exact-or-fail", order: 751, err: ErrNoSolution},
+		{strategy: "exact-or-fail", order: 10_000_250, expected: append(repeat(5000, 2000), 250)},
+		{strategy: "max-overage-249", order: 751, expected: []int{1000}},
+		{strategy: "max-overage-100", order: 751, err: ErrNoSolution},
+		{strategy: "max-overage-100000", order: 751, expected: []int{1000}},
+		{strategy: "under-fulfil", order: 751, expected: []int{500, 250}},
+		{strategy: "under-fulfil", order: 999, expected: []int{500, 250}},
+		{strategy: "under-fulfil", order: 1000, expected: []int{1000}},
+		{strategy: "under-fulfil", order: 100, expected: []int{}},
+		{strategy: "under-fulfil", order: 10_000_251, expected: append(repeat(5000, 2000), 250)},
+	}
+
+	repo := New()
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			s, err := LookupStrategy(test.strategy)
+			assert.NoError(t, err)
+
+			res, err := repo.Solve(context.Background(), packs, test.order, Options{Strategy: s})
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, res.Packages)
+		})
+	}
+}
+
+func TestRegisterStrategy(t *testing.T) {
+	RegisterStrategy("test-at-least-double", func(param string) (Strategy, error) {
+		return doubleStrategy{}, nil
+	})
+
+	s, err := LookupStrategy("test-at-least-double")
+	assert.NoError(t, err)
+	assert.Contains(t, Strategies(), "test-at-least-double")
+
+	res, err := New().Solve(context.Background(), packs, 600, Options{Strategy: s})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1000, 250}, res.Packages)
+}
+
+// doubleStrategy sends at least twice the order.
+type doubleStrategy struct{}
+
+func (doubleStrategy) Name() string {
+	return "test-at-least-double"
+}
+
+func (doubleStrategy) Window(target int) (int, int) {
+	return 2 * target, math.MaxInt
+}
+
+func (doubleStrategy) Objective() Objective {
+	return Objective{CriterionItems, CriterionPacks}
+}
+
+// repeat returns a slice holding n copies of pack.
+func repeat(pack, n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = pack
+	}
+
+	return out
+}
+
+func TestSolveUnderFulfil(t *testing.T) {
+	s, err := LookupStrategy("under-fulfil")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		stock    map[int]int
+		order    int
+		expected []int
+	}{
+		{stock: map[int]int{250: 0}, order: 999, expected: []int{500}},
+		// adding a pack is only possible while there's one left, so the closest total can be far below the order.
+		{stock: map[int]int{250: 1, 500: 0, 1000: 0, 2000: 0, 5000: 0}, order: 12_000, expected: []int{250}},
+		{stock: map[int]int{5000: 1}, order: 12_001, expected: []int{5000, 2000, 2000, 2000, 1000}},
+		// with nothing in stock, nothing is sent.
+		{stock: map[int]int{250: 0, 500: 0, 1000: 0, 2000: 0, 5000: 0}, order: 999, expected: []int{}},
+	}
+
+	for _, repo := range []*Packager{New(), NewCached(NewCache(1 << 20))} {
+		for i, test := range tests {
+			t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+				res, err := repo.Solve(context.Background(), packs, test.order, Options{Strategy: s, Stock: test.stock})
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, res.Packages)
+			})
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package packing
+
+import (
+	"context"
+	"math"
+	"unsafe"
+)
+
+// cancelCheckInterval is the number of cells tabulated between two checks of the context. Checking on every cell would
+// cost more than the cell itself.
+const cancelCheckInterval = 1 << 12
+
+// unreachable marks a total that no combination of packs adds up to. It is kept well below math.MaxInt so that adding
+// a box to it can never overflow.
+const unreachable = math.MaxInt32
+
+// table is the tabulation behind Calculate. Instead of keeping a full combination for every total, which grows with
+// len(packs) per cell, we only keep the best score for each total and the pack that was added last to reach it.
+// That back-pointer is enough to walk any solution back down to zero.
+type table struct {
+	packs     []int
+	weights   []score
+	objective Objective
+	scores    []score
+	last      []int
+
+	// a table of limited stock has no back-pointers, it records for every bundle of packs whether it was taken for
+	// a total instead, see solveBounded.
+	bundles []item
+	taken   [][]uint64
+}
+
+// newTable tabulates every total from 0 up to and including limit. packs must be sorted in ascending order, weights
+// holds what a single pack of each size adds to a score and objective must be normalised.
+// It returns the context's error if the context is done before the table is complete.
+func newTable(ctx context.Context, packs []int, weights []score, objective Objective, limit int) (*table, error) {
+	// first box is always the 0th case, making the least viable solution to any order to send 0 boxes.
+	t := &table{
+		packs:     packs,
+		weights:   weights,
+		objective: objective,
+		scores:    []score{{}},
+		last:      []int{-1},
+	}
+
+	return t.extend(ctx, limit)
+}
+
+// extend returns a table that goes up to and including limit, or t itself if it already does. A row never depends on
+// the rows above it, so the rows of t are copied rather than tabulated again. t is left untouched, which keeps it safe
+// to read while it is being extended.
+func (t *table) extend(ctx context.Context, limit int) (*table, error) {
+	if limit < len(t.scores) {
+		return t, nil
+	}
+
+	out := &table{
+		packs:     t.packs,
+		weights:   t.weights,
+		objective: t.objective,
+		scores:    make([]score, limit+1),
+		last:      make([]int, limit+1),
+	}
+	copy(out.scores, t.scores)
+	copy(out.last, t.last)
+
+	for i := len(t.scores); i <= limit; i++ {
+		if i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		out.scores[i] = score{packs: unreachable}
+		out.last[i] = -1
+
+		for j, pack := range out.packs {
+			// packs are sorted, so nothing after this one fits either.
+			if pack > i {
+				break
+			}
+
+			if !out.reachable(i - pack) {
+				continue
+			}
+
+			// only replace on a strictly better score, which keeps the first (smallest) pack on ties, just like the
+			// original tabulation did.
+			candidate := out.scores[i-pack].add(out.weights[j])
+			if !out.reachable(i) || out.objective.compareScores(candidate, out.scores[i]) < 0 {
+				out.scores[i] = candidate
+				out.last[i] = j
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// size returns the approximate number of bytes the table holds on to.
+func (t *table) size() int {
+	return len(t.scores)*int(unsafe.Sizeof(score{})) + len(t.last)*int(unsafe.Sizeof(0))
+}
+
+// reachable reports whether some combination of packs adds up to total.
+func (t *table) reachable(total int) bool {
+	return t.scores[total].packs != unreachable
+}
+
+// best returns the reachable total between from and to, inclusive, that ranks best under the objective. It returns -1
+// if there is none. below ranks totals for sending less than the order, where the larger ones are the closer ones.
+func (t *table) best(from, to int, below bool) int {
+	first, last, step := from, to, 1
+	if below {
+		first, last, step = to, from, -1
+	}
+
+	out := -1
+	for i := first; i*step <= last*step; i += step {
+		if !t.reachable(i) {
+			continue
+		}
+
+		// with items first, the closest reachable total always wins.
+		if t.objective[0] == CriterionItems {
+			return i
+		}
+
+		if out == -1 || t.compare(i, out, below) < 0 {
+			out = i
+		}
+	}
+
+	return out
+}
+
+// compare ranks the reachable totals a and b under the objective, see best for below.
+func (t *table) compare(a, b int, below bool) int {
+	aTotal, bTotal := a, b
+	if below {
+		aTotal, bTotal = -a, -b
+	}
+
+	return t.objective.compare(aTotal, t.scores[a], bTotal, t.scores[b])
+}
+
+// counts walks the back-pointers from total down to zero and returns the number of repetitions for each pack.
+func (t *table) counts(total int) []int {
+	out := make([]int, len(t.packs))
+	if t.taken != nil {
+		// the last bundle to touch a total decided its final value, so we walk the bundles backwards.
+		for k := len(t.bundles) - 1; k >= 0; k-- {
+			if t.taken[k][total/64]&(1<<(total%64)) != 0 {
+				out[t.bundles[k].index] += t.bundles[k].count
+				total -= t.bundles[k].count * t.packs[t.bundles[k].index]
+			}
+		}
+
+		return out
+	}
+
+	for i := total; i > 0; i -= t.packs[t.last[i]] {
+		out[t.last[i]]++
+	}
+
+	return out
+}
+
+// reductionBound returns the total above which some best distribution contains the dominant pack d, and every best
+// distribution does so when d is strictly better per item than the rest, as the largest pack is under the default
+// objective. If another pack c is used d/gcd(c, d) times, the same items can be sent as c/gcd(c, d) dominant packs,
+// which never ranks worse. So the part of a best distribution that is not made of the dominant pack never needs to
+// exceed the sum below.
+func reductionBound(packs []int, d int) int {
+	bound := 0
+	for i, pack := range packs {
+		if i != d {
+			bound += pack * (packs[d]/gcd(pack, packs[d]) - 1)
+		}
+	}
+
+	return bound
+}
+
+// gcd returns the greatest common divisor of a and b.
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
@@ -0,0 +1,144 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+)
+
+var (
+	// ErrJobNotFound is returned for jobs that never existed or were already dropped.
+	ErrJobNotFound = fmt.Errorf("recommendation job not found")
+	// ErrTooManyJobs is returned by Start while the most jobs allowed are running.
+	ErrTooManyJobs = fmt.Errorf("too many recommendation jobs are running")
+)
+
+// keptJobs is the number of jobs kept around, the oldest finished ones are dropped first.
+const keptJobs = 100
+
+// Status is the state of a job.
+type Status string
+
+const (
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// Job is a recommendation running in the background. Jobs returned by Jobs are snapshots and safe to read.
+type Job struct {
+	ID       string
+	Status   Status
+	Created  time.Time
+	Finished time.Time
+	Result   *Recommendation
+	Err      error
+}
+
+// Jobs runs recommendations in the background, since searching pack sets takes far longer than a request may.
+type Jobs struct {
+	lock    sync.Mutex
+	r       *Recommender
+	timeout time.Duration
+	limit   int // upper bound of running jobs
+	running int
+	ctx     context.Context
+	cancel  context.CancelFunc
+	jobs    map[string]*Job
+	order   []string // job ids, oldest first
+}
+
+// NewJobs returns a job runner that gives every job at most timeout to finish, and runs at most limit jobs at once.
+// Searches are expensive, so a job that would exceed the limit is refused rather than queued.
+func NewJobs(r *Recommender, timeout time.Duration, limit int) *Jobs {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Jobs{
+		r:       r,
+		timeout: timeout,
+		limit:   limit,
+		ctx:     ctx,
+		cancel:  cancel,
+		jobs:    map[string]*Job{},
+	}
+}
+
+// Start starts a recommendation for h and returns the job tracking it.
+func (j *Jobs) Start(h Histogram, opts Options) (Job, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	job := &Job{ID: id, Status: StatusRunning, Created: time.Now()}
+
+	j.lock.Lock()
+	if j.running >= j.limit {
+		j.lock.Unlock()
+		return Job{}, ErrTooManyJobs
+	}
+
+	j.running++
+	j.jobs[id] = job
+	j.order = append(j.order, id)
+	j.drop()
+	snapshot := *job
+	j.lock.Unlock()
+
+	// jobs outlive the request that started them, so they hang off the runner's context rather than the request's.
+	go func() {
+		ctx, cancel := context.WithTimeout(j.ctx, j.timeout)
+		defer cancel()
+
+		res, err := j.r.Recommend(ctx, h, opts)
+
+		j.lock.Lock()
+		defer j.lock.Unlock()
+
+		j.running--
+		job.Finished = time.Now()
+		job.Result, job.Err = res, err
+		job.Status = StatusDone
+		if err != nil {
+			job.Status = StatusFailed
+		}
+	}()
+
+	return snapshot, nil
+}
+
+// Get returns a snapshot of the job with id.
+func (j *Jobs) Get(id string) (Job, error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	job, ok := j.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+
+	return *job, nil
+}
+
+// Close cancels every running job.
+func (j *Jobs) Close() {
+	j.cancel()
+}
+
+// drop forgets the oldest finished jobs while there are more than keptJobs. Running jobs are never dropped. The lock
+// must be held.
+func (j *Jobs) drop() {
+	for i := 0; len(j.jobs) > keptJobs && i < len(j.order); {
+		id := j.order[i]
+		if j.jobs[id].Status == StatusRunning {
+			i++
+			continue
+		}
+
+		delete(j.jobs, id)
+		j.order = append(j.order[:i], j.order[i+1:]...)
+	}
+}
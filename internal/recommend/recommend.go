@@ -0,0 +1,346 @@
+package recommend
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"retask/internal/packing"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// This package recommends which pack sizes to stock, based on the orders that came in so far. It only ranks pack sets,
+// the packing itself is left to the packaging repo behind Calculator.
+
+var (
+	ErrHistogramEmpty    = fmt.Errorf("histogram has no orders")
+	ErrHistogramInvalid  = fmt.Errorf("histogram is invalid")
+	ErrMaxSizesInvalid   = fmt.Errorf("max sizes is not positive")
+	ErrCandidateInvalid  = fmt.Errorf("candidate size is not positive")
+	ErrHistogramTooLarge = fmt.Errorf("histogram totals are too large to count")
+	ErrTooManyPacks      = fmt.Errorf("every pack set needs more packs than allowed for some order")
+)
+
+// defaultCandidates is the number of most frequent order quantities tried as pack sizes when no candidates are given.
+const defaultCandidates = 40
+
+// maxSwapRounds caps the rounds of the local search, every round tries every swap once.
+const maxSwapRounds = 10
+
+// Calculator packs a single order, packing.Packager implements it.
+type Calculator interface {
+	Solve(ctx context.Context, packs []int, target int, opts packing.Options) (*packing.Result, error)
+}
+
+// Limits bound the packing of a recommendation like that of a request, zero doesn't limit anything.
+type Limits struct {
+	// MaxPacks caps the packs of a single order. A pack set that needs more for any order of the histogram would have
+	// that order rejected, so it is never recommended.
+	MaxPacks int
+	// MaxTableBytes caps the memory of every table, see packing.Options.MaxTableBytes.
+	MaxTableBytes int
+}
+
+// Histogram maps an order quantity to the number of times it was ordered.
+type Histogram map[int]int
+
+// Entry is a single bucket of a histogram, as it is read from JSON.
+type Entry struct {
+	Quantity int `json:"quantity"`
+	Count    int `json:"count"`
+}
+
+// Options tune a recommendation.
+type Options struct {
+	// MaxSizes is the largest number of pack sizes to recommend.
+	MaxSizes int
+	// Candidates are the sizes to choose from, the most frequent order quantities are used if empty.
+	Candidates []int
+}
+
+// Recommendation is the best pack set found, together with what it would have shipped for the histogram.
+type Recommendation struct {
+	Sizes []int
+	// Orders is the number of orders in the histogram.
+	Orders int64
+	// Overage is the number of items shipped above the orders, PackCount the number of packs shipped, both summed
+	// over every order of the histogram. Counts are int64 like order quantities, histograms that don't fit fail with
+	// ErrHistogramTooLarge.
+	Overage   int64
+	PackCount int64
+	// Evaluated is the number of pack sets that were tried.
+	Evaluated int
+}
+
+// Recommender searches pack sets for the one that ships the fewest items above the orders, then the fewest packs,
+// which are rules 2 and 3 applied to the whole history instead of a single order.
+type Recommender struct {
+	calc   Calculator
+	limits Limits
+}
+
+func New(calc Calculator, limits Limits) *Recommender {
+	return &Recommender{calc: calc, limits: limits}
+}
+
+// ParseCSV reads a histogram from lines of quantity,count. A header line that isn't numeric is skipped.
+func ParseCSV(r io.Reader) (Histogram, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHistogramInvalid, err)
+	}
+
+	entries := make([]Entry, 0, len(records))
+	for i, record := range records {
+		quantity, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil && i == 0 {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrHistogramInvalid, i+1, err)
+		}
+
+		count, err := strconv.Atoi(strings.TrimSpace(record[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrHistogramInvalid, i+1, err)
+		}
+
+		entries = append(entries, Entry{Quantity: quantity, Count: count})
+	}
+
+	return NewHistogram(entries)
+}
+
+// ParseJSON reads a histogram from a JSON array of entries.
+func ParseJSON(r io.Reader) (Histogram, error) {
+	var entries []Entry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHistogramInvalid, err)
+	}
+
+	return NewHistogram(entries)
+}
+
+// NewHistogram builds a histogram from entries, adding up entries of the same quantity.
+func NewHistogram(entries []Entry) (Histogram, error) {
+	h := make(Histogram, len(entries))
+	for _, e := range entries {
+		if e.Quantity <= 0 || e.Count < 0 {
+			return nil, fmt.Errorf("%w: quantity %d with count %d", ErrHistogramInvalid, e.Quantity, e.Count)
+		}
+
+		if e.Count > 0 {
+			h[e.Quantity] += e.Count
+		}
+	}
+
+	if len(h) == 0 {
+		return nil, ErrHistogramEmpty
+	}
+
+	return h, nil
+}
+
+// Recommend returns the best pack set of at most opts.MaxSizes candidates for h.
+//
+// Trying every combination of candidates is out of the question, so the set is grown greedily, always adding the
+// candidate that helps most, and then improved by swapping single sizes for as long as a swap helps. Every pack set is
+// scored by packing the whole histogram with it. Pack sets that exceed limits.MaxPacks for some order are skipped, it
+// returns ErrTooManyPacks if that leaves none.
+func (r *Recommender) Recommend(ctx context.Context, h Histogram, opts Options) (*Recommendation, error) {
+	if len(h) == 0 {
+		return nil, ErrHistogramEmpty
+	}
+
+	if opts.MaxSizes <= 0 {
+		return nil, ErrMaxSizesInvalid
+	}
+
+	candidates, err := candidatesOf(h, opts.Candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &search{calc: r.calc, limits: r.limits, histogram: h}
+	var best *Recommendation
+	chosen := []int{}
+
+	// grow the set for as long as a size helps.
+	for len(chosen) < opts.MaxSizes {
+		var next *Recommendation
+		for _, c := range candidates {
+			if slices.Contains(chosen, c) {
+				continue
+			}
+
+			rec, err := s.evaluate(ctx, append(slices.Clone(chosen), c))
+			if err != nil {
+				return nil, err
+			}
+
+			if rec != nil && (next == nil || better(rec, next)) {
+				next = rec
+			}
+		}
+
+		if next == nil || (best != nil && !better(next, best)) {
+			break
+		}
+
+		best = next
+		chosen = next.Sizes
+	}
+
+	if best == nil {
+		return nil, ErrTooManyPacks
+	}
+
+	// then swap sizes in and out for as long as that helps.
+	for round := 0; round < maxSwapRounds; round++ {
+		improved := false
+		for i := range chosen {
+			for _, c := range candidates {
+				if slices.Contains(chosen, c) {
+					continue
+				}
+
+				sizes := slices.Clone(chosen)
+				sizes[i] = c
+
+				rec, err := s.evaluate(ctx, sizes)
+				if err != nil {
+					return nil, err
+				}
+
+				if rec != nil && better(rec, best) {
+					best, chosen, improved = rec, rec.Sizes, true
+				}
+			}
+		}
+
+		if !improved {
+			break
+		}
+	}
+
+	best.Evaluated = s.evaluated
+
+	return best, nil
+}
+
+// candidatesOf returns the sizes to choose from, sorted in ascending order.
+func candidatesOf(h Histogram, given []int) ([]int, error) {
+	if len(given) > 0 {
+		for _, c := range given {
+			if c <= 0 {
+				return nil, ErrCandidateInvalid
+			}
+		}
+
+		out := slices.Clone(given)
+		sort.Ints(out)
+
+		return slices.Compact(out), nil
+	}
+
+	// a pack the size of an order ships that order without overage, so frequent quantities make good candidates.
+	out := make([]int, 0, len(h))
+	for quantity := range h {
+		out = append(out, quantity)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if h[out[i]] != h[out[j]] {
+			return h[out[i]] > h[out[j]]
+		}
+
+		return out[i] < out[j]
+	})
+
+	out = out[:min(defaultCandidates, len(out))]
+	sort.Ints(out)
+
+	return out, nil
+}
+
+// better reports whether a ranks ahead of b.
+func better(a, b *Recommendation) bool {
+	if a.Overage != b.Overage {
+		return a.Overage < b.Overage
+	}
+
+	if a.PackCount != b.PackCount {
+		return a.PackCount < b.PackCount
+	}
+
+	// fewer sizes are easier to stock.
+	return len(a.Sizes) < len(b.Sizes)
+}
+
+// search scores pack sets against a histogram.
+type search struct {
+	calc      Calculator
+	limits    Limits
+	histogram Histogram
+	evaluated int
+}
+
+// evaluate packs every order of the histogram with sizes. It returns nil if sizes need more packs than allowed for
+// some order.
+func (s *search) evaluate(ctx context.Context, sizes []int) (*Recommendation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.evaluated++
+
+	// only the totals count, so the packages are never listed, which would grow with the order.
+	opts := packing.Options{MaxTableBytes: s.limits.MaxTableBytes, OmitPackages: true}
+
+	sort.Ints(sizes)
+	rec := &Recommendation{Sizes: sizes}
+	for quantity, count := range s.histogram {
+		res, err := s.calc.Solve(ctx, slices.Clone(sizes), quantity, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		// a distribution never holds more than a largest pack above its order, which the packaging repo keeps countable,
+		// and never more packs than items.
+		items, packs := 0, 0
+		for _, pack := range res.Packs {
+			items += pack.Size * pack.Quantity
+			packs += pack.Quantity
+		}
+
+		if s.limits.MaxPacks > 0 && packs > s.limits.MaxPacks {
+			return nil, nil
+		}
+
+		var ok [3]bool
+		rec.Orders, ok[0] = mulAdd(rec.Orders, 1, int64(count))
+		rec.Overage, ok[1] = mulAdd(rec.Overage, int64(items-quantity), int64(count))
+		rec.PackCount, ok[2] = mulAdd(rec.PackCount, int64(packs), int64(count))
+		if !ok[0] || !ok[1] || !ok[2] {
+			return nil, ErrHistogramTooLarge
+		}
+	}
+
+	return rec, nil
+}
+
+// mulAdd returns sum + a*b for non-negative numbers, false if that doesn't fit into an int64.
+func mulAdd(sum, a, b int64) (int64, bool) {
+	if a != 0 && b > (math.MaxInt64-sum)/a {
+		return 0, false
+	}
+
+	return sum + a*b, true
+}
@@ -0,0 +1,144 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"retask/internal/packing"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		csv      string
+		json     string
+		expected Histogram
+		err      error
+	}{
+		{
+			csv:      "quantity,count\n250,10\n500, 5\n250,1\n",
+			json:     `[{"quantity": 250, "count": 10}, {"quantity": 500, "count": 5}, {"quantity": 250, "count": 1}]`,
+			expected: Histogram{250: 11, 500: 5},
+		},
+		{csv: "1,0\n", json: `[{"quantity": 1, "count": 0}]`, err: ErrHistogramEmpty},
+		{csv: "0,1\n", json: `[{"quantity": 0, "count": 1}]`, err: ErrHistogramInvalid},
+		{csv: "1,1\nx,1\n", json: `{"quantity": 1}`, err: ErrHistogramInvalid},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			fromCSV, err := ParseCSV(strings.NewReader(test.csv))
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, test.expected, fromCSV)
+
+			fromJSON, err := ParseJSON(strings.NewReader(test.json))
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, test.expected, fromJSON)
+		})
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	tests := []struct {
+		histogram Histogram
+		opts      Options
+		expected  []int
+		overage   int64
+		packCount int64
+		err       error
+	}{
+		{histogram: Histogram{250: 10, 500: 5}, opts: Options{MaxSizes: 2}, expected: []int{250, 500}, packCount: 15},
+		{histogram: Histogram{250: 10, 500: 5}, opts: Options{MaxSizes: 1}, expected: []int{250}, packCount: 20},
+		// a single size already ships without overage, a second one only saves packs.
+		{histogram: Histogram{100: 1, 300: 1}, opts: Options{MaxSizes: 3}, expected: []int{100, 300}, packCount: 2},
+		{histogram: Histogram{100: 1, 300: 1}, opts: Options{MaxSizes: 3, Candidates: []int{100, 50}}, expected: []int{100},
+			packCount: 4},
+		{histogram: Histogram{7: 3, 12: 1}, opts: Options{MaxSizes: 1, Candidates: []int{5}}, expected: []int{5}, overage: 12,
+			packCount: 9},
+		{histogram: Histogram{1: 1}, opts: Options{MaxSizes: 0}, err: ErrMaxSizesInvalid},
+		{histogram: Histogram{1: 1}, opts: Options{MaxSizes: 1, Candidates: []int{-1}}, err: ErrCandidateInvalid},
+		{histogram: Histogram{}, opts: Options{MaxSizes: 1}, err: ErrHistogramEmpty},
+		// the overage of every single order fits, only summed over its count it doesn't.
+		{histogram: Histogram{1: math.MaxInt64 / 2}, opts: Options{MaxSizes: 1, Candidates: []int{4}},
+			err: ErrHistogramTooLarge},
+		// a single pack of 1 would need 10^12 packs for the large order, which is never listed, only skipped.
+		{histogram: Histogram{1_000_000_000_000: 1, 1: 1}, opts: Options{MaxSizes: 1}, expected: []int{1_000_000_000_000},
+			overage: 999_999_999_999, packCount: 2},
+		{histogram: Histogram{10_000_000: 1}, opts: Options{MaxSizes: 1, Candidates: []int{1}}, err: ErrTooManyPacks},
+	}
+
+	r := New(packing.New(), Limits{MaxPacks: 1_000_000})
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			out, err := r.Recommend(context.Background(), test.histogram, test.opts)
+			assert.ErrorIs(t, err, test.err)
+			if test.err != nil {
+				return
+			}
+
+			assert.Equal(t, test.expected, out.Sizes)
+			assert.Equal(t, test.overage, out.Overage)
+			assert.Equal(t, test.packCount, out.PackCount)
+		})
+	}
+}
+
+func TestRecommendCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := New(packing.New(), Limits{}).Recommend(ctx, Histogram{250: 1}, Options{MaxSizes: 1})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestJobs(t *testing.T) {
+	jobs := NewJobs(New(packing.New(), Limits{}), time.Minute, 1)
+	defer jobs.Close()
+
+	job, err := jobs.Start(Histogram{250: 10, 500: 5}, Options{MaxSizes: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, StatusRunning, job.Status)
+
+	assert.Eventually(t, func() bool {
+		job, err = jobs.Get(job.ID)
+		return err == nil && job.Status != StatusRunning
+	}, 10*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, StatusDone, job.Status)
+	assert.Equal(t, []int{250, 500}, job.Result.Sizes)
+
+	_, err = jobs.Get("unknown")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+// blockingCalculator never finishes a calculation before its context is done.
+type blockingCalculator struct{}
+
+func (blockingCalculator) Solve(ctx context.Context, _ []int, _ int, _ packing.Options) (*packing.Result, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestJobsLimit(t *testing.T) {
+	jobs := NewJobs(New(blockingCalculator{}, Limits{}), 100*time.Millisecond, 1)
+	defer jobs.Close()
+
+	first, err := jobs.Start(Histogram{250: 1}, Options{MaxSizes: 1})
+	assert.NoError(t, err)
+
+	_, err = jobs.Start(Histogram{250: 1}, Options{MaxSizes: 1})
+	assert.ErrorIs(t, err, ErrTooManyJobs)
+
+	// once the running job is done, there is room again.
+	assert.Eventually(t, func() bool {
+		job, err := jobs.Get(first.ID)
+		return err == nil && job.Status != StatusRunning
+	}, 10*time.Second, 10*time.Millisecond)
+
+	_, err = jobs.Start(Histogram{250: 1}, Options{MaxSizes: 1})
+	assert.NoError(t, err)
+}
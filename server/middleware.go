@@ -111,10 +111,25 @@ func getRealIP(r *http.Request) string {
 func CORSMiddleware() func(next http.Handler) http.Handler {
 	allowed := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
-		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders: []string{"Authorization", "Content-Type", "X-Request-Id",
-			"X-Forwarded-For", "True-Client-IP", "X-Real-IP"},
+			"X-Forwarded-For", "True-Client-IP", "X-Real-IP", "If-Match", "X-Operator"},
+		ExposedHeaders:   []string{"ETag"},
 		AllowCredentials: true,
 	})
 	return allowed.Handler
 }
+
+// timeout cancels the context of a request after d. Unlike middleware.Timeout it doesn't write a 504 of its own once the
+// time is up, the handlers already answer a calculation that ran out of time in the error envelope, and writing the
+// status twice only gets the second one logged as superfluous.
+func timeout(d time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
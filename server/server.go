@@ -41,13 +41,37 @@ func New(conf *config.Config, handlers *handler.Handler) (*Server, error) {
 	router.Use(middleware.Recoverer)
 
 	// timeout on the request using context.Done()
-	router.Use(middleware.Timeout(conf.HttpTimeout))
+	router.Use(timeout(conf.HttpTimeout))
+
+	// unknown routes and methods are answered in the error envelope, like every other error.
+	router.NotFound(handlers.NotFound)
+	router.MethodNotAllowed(handlers.MethodNotAllowed)
 
 	// define the endpoints
 	// update-package-size is a POST request
 	router.Post("/update-package-sizes", handlers.UpdatePackageSizes)
+	// pack-sizes reads the sizes with a GET request, replaces them with a PUT request like update-package-sizes, adds a
+	// single size with a POST request and removes one with a DELETE request on pack-sizes/{size}
+	router.Get("/pack-sizes", handlers.GetPackSizes)
+	router.Put("/pack-sizes", handlers.UpdatePackageSizes)
+	router.Post("/pack-sizes", handlers.AddPackSize)
+	router.Delete("/pack-sizes/{size}", handlers.DeletePackSize)
+	// pack-sizes/history is a GET request, pack-sizes/rollback/{version} is a POST request setting an earlier version
+	router.Get("/pack-sizes/history", handlers.GetPackSizesHistory)
+	router.Post("/pack-sizes/rollback/{version}", handlers.RollbackPackSizes)
 	// calculate-best-packages is a POST request
 	router.Post("/calculate-best-packages", handlers.CalculateBestPackages)
+	router.Post("/calculate-best-packages/batch", handlers.CalculateBestPackagesBatch)
+	router.Post("/v2/calculate-best-packages", handlers.CalculateBestPackagesV2)
+	// calculate-order-packages is a POST request
+	router.Post("/calculate-order-packages", handlers.CalculateOrderPackages)
+	// pack-sets/analyze is a POST request
+	router.Post("/pack-sets/analyze", handlers.AnalyzePackSet)
+	// recommendations is a POST request starting a job, recommendations/{id} is a GET request polling it
+	router.Post("/recommendations", handlers.StartRecommendation)
+	router.Get("/recommendations/{id}", handlers.GetRecommendation)
+	// cache-stats is a GET request
+	router.Get("/cache-stats", handlers.CacheStats)
 	// ping
 	router.Get("/ping", func(writer http.ResponseWriter, request *http.Request) {
 		if _, err := writer.Write([]byte("pong")); err != nil {